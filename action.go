@@ -0,0 +1,286 @@
+package sdk
+
+import (
+	"context"
+	"sync"
+)
+
+// Action is an instance of a plugin action, created for each context by an ActionFactory.
+// It handles events by implementing any of the optional interfaces:
+// KeyDownHandler, KeyUpHandler, WillAppearHandler, WillDisappearHandler, DialRotateHandler, DialDownHandler,
+// DialUpHandler, TouchTapHandler, SettingsHandler, SendToPluginHandler, TitleParametersHandler,
+// PropertyInspectorDidAppearHandler and PropertyInspectorDidDisappearHandler.
+type Action interface{}
+
+// ActionFactory creates the Action of the instance with given context.
+// It is called when the first event of a visible instance is received, before dispatch, so it must not block.
+type ActionFactory func(context string) Action
+
+// KeyDownHandler is implemented by actions handling keyDown events.
+type KeyDownHandler interface {
+	OnKeyDown(ctx context.Context, event *ReceivedEvent) error
+}
+
+// KeyUpHandler is implemented by actions handling keyUp events.
+type KeyUpHandler interface {
+	OnKeyUp(ctx context.Context, event *ReceivedEvent) error
+}
+
+// WillAppearHandler is implemented by actions handling willAppear events.
+type WillAppearHandler interface {
+	OnWillAppear(ctx context.Context, event *ReceivedEvent) error
+}
+
+// WillDisappearHandler is implemented by actions handling willDisappear events.
+// The instance is dropped afterwards.
+type WillDisappearHandler interface {
+	OnWillDisappear(ctx context.Context, event *ReceivedEvent) error
+}
+
+// DialRotateHandler is implemented by actions handling dialRotate events.
+type DialRotateHandler interface {
+	OnDialRotate(ctx context.Context, event *ReceivedEvent) error
+}
+
+// DialDownHandler is implemented by actions handling dialDown events.
+type DialDownHandler interface {
+	OnDialDown(ctx context.Context, event *ReceivedEvent) error
+}
+
+// DialUpHandler is implemented by actions handling dialUp events.
+type DialUpHandler interface {
+	OnDialUp(ctx context.Context, event *ReceivedEvent) error
+}
+
+// TouchTapHandler is implemented by actions handling touchTap events.
+type TouchTapHandler interface {
+	OnTouchTap(ctx context.Context, event *ReceivedEvent) error
+}
+
+// SettingsHandler is implemented by actions handling didReceiveSettings events.
+type SettingsHandler interface {
+	OnSettings(ctx context.Context, event *ReceivedEvent) error
+}
+
+// SendToPluginHandler is implemented by actions handling sendToPlugin events from their Property Inspector.
+type SendToPluginHandler interface {
+	OnSendToPlugin(ctx context.Context, event *ReceivedEvent) error
+}
+
+// TitleParametersHandler is implemented by actions handling titleParametersDidChange events.
+type TitleParametersHandler interface {
+	OnTitleParametersDidChange(ctx context.Context, event *ReceivedEvent) error
+}
+
+// PropertyInspectorDidAppearHandler is implemented by actions handling propertyInspectorDidAppear events.
+type PropertyInspectorDidAppearHandler interface {
+	OnPropertyInspectorDidAppear(ctx context.Context, event *ReceivedEvent) error
+}
+
+// PropertyInspectorDidDisappearHandler is implemented by actions handling propertyInspectorDidDisappear events.
+type PropertyInspectorDidDisappearHandler interface {
+	OnPropertyInspectorDidDisappear(ctx context.Context, event *ReceivedEvent) error
+}
+
+// actions routes events of registered actions to their instances.
+type actions struct {
+	mu        sync.Mutex
+	factories map[string]ActionFactory
+	instances map[string]Action
+
+	// manifests describes registered actions in registration order
+	manifests []ManifestAction
+}
+
+// ActionOption configures a registered action.
+type ActionOption func(*ManifestAction)
+
+// Describe sets the manifest entry of the action, used to generate manifest.json.
+// Its UUID is set to the registered one.
+func Describe(manifest ManifestAction) ActionOption {
+	return func(m *ManifestAction) {
+		*m = manifest
+	}
+}
+
+func newActions() *actions {
+	return &actions{
+		factories: make(map[string]ActionFactory),
+		instances: make(map[string]Action),
+	}
+}
+
+// observe binds given event to the instance of its registered action, creating it if the instance is visible.
+// Instances are created and dropped in the order events are received, whatever the dispatch mode:
+// the instance of a WillDisappear event is dropped at once, and only bound to the event to be notified.
+func (a *actions) observe(event *ReceivedEvent, visible bool) {
+	if event.Context == "" {
+		return
+	}
+
+	a.mu.Lock()
+	factory, registered := a.factories[event.Action]
+	action, ok := a.instances[event.Context]
+	if event.Event == WillDisappear {
+		delete(a.instances, event.Context)
+	}
+	a.mu.Unlock()
+
+	if !registered {
+		return
+	}
+
+	if !ok {
+		// Late events of a disappeared instance must not create it again.
+		if !visible {
+			return
+		}
+
+		// The factory is called without holding the lock, so it may use ActionInstance.
+		action = factory(event.Context)
+
+		a.mu.Lock()
+		a.instances[event.Context] = action
+		a.mu.Unlock()
+	}
+
+	event.action = action
+}
+
+// get returns the action of given context.
+func (a *actions) get(context string) (Action, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	action, ok := a.instances[context]
+	return action, ok
+}
+
+// describe records the manifest entry of an action, replacing a previous one with the same UUID.
+func (a *actions) describe(manifest ManifestAction) {
+	for i := range a.manifests {
+		if a.manifests[i].UUID == manifest.UUID {
+			a.manifests[i] = manifest
+			return
+		}
+	}
+
+	a.manifests = append(a.manifests, manifest)
+}
+
+// handle calls the method of the action matching given event.
+func (a *actions) handle(ctx context.Context, event *ReceivedEvent) error {
+	action := event.action
+	if action == nil {
+		return nil
+	}
+
+	switch event.Event {
+	case KeyDown:
+		if h, ok := action.(KeyDownHandler); ok {
+			return h.OnKeyDown(ctx, event)
+		}
+	case KeyUp:
+		if h, ok := action.(KeyUpHandler); ok {
+			return h.OnKeyUp(ctx, event)
+		}
+	case WillAppear:
+		if h, ok := action.(WillAppearHandler); ok {
+			return h.OnWillAppear(ctx, event)
+		}
+	case WillDisappear:
+		if h, ok := action.(WillDisappearHandler); ok {
+			return h.OnWillDisappear(ctx, event)
+		}
+	case DialRotate:
+		if h, ok := action.(DialRotateHandler); ok {
+			return h.OnDialRotate(ctx, event)
+		}
+	case DialDown:
+		if h, ok := action.(DialDownHandler); ok {
+			return h.OnDialDown(ctx, event)
+		}
+	case DialUp:
+		if h, ok := action.(DialUpHandler); ok {
+			return h.OnDialUp(ctx, event)
+		}
+	case TouchTap:
+		if h, ok := action.(TouchTapHandler); ok {
+			return h.OnTouchTap(ctx, event)
+		}
+	case DidReceiveSettings:
+		if h, ok := action.(SettingsHandler); ok {
+			return h.OnSettings(ctx, event)
+		}
+	case SendToPlugin:
+		if h, ok := action.(SendToPluginHandler); ok {
+			return h.OnSendToPlugin(ctx, event)
+		}
+	case TitleParametersDidChange:
+		if h, ok := action.(TitleParametersHandler); ok {
+			return h.OnTitleParametersDidChange(ctx, event)
+		}
+	case PropertyInspectorDidAppear:
+		if h, ok := action.(PropertyInspectorDidAppearHandler); ok {
+			return h.OnPropertyInspectorDidAppear(ctx, event)
+		}
+	case PropertyInspectorDidDisappear:
+		if h, ok := action.(PropertyInspectorDidDisappearHandler); ok {
+			return h.OnPropertyInspectorDidDisappear(ctx, event)
+		}
+	}
+
+	return nil
+}
+
+// RegisterAction registers the factory creating an Action for each instance of the action with given UUID.
+// Events of the action are routed to the methods its instances implement.
+func (s *StreamDeck) RegisterAction(uuid string, factory ActionFactory, opts ...ActionOption) {
+	s.actions.mu.Lock()
+	defer s.actions.mu.Unlock()
+
+	if s.manifest != nil {
+		if _, ok := s.manifest.Action(uuid); !ok {
+			s.Logf("[WARN] registered action [%s] is not in the manifest", uuid)
+		}
+	}
+
+	s.actions.factories[uuid] = factory
+	if len(opts) > 0 {
+		var manifest ManifestAction
+		for _, opt := range opts {
+			opt(&manifest)
+		}
+
+		manifest.UUID = uuid
+		s.actions.describe(manifest)
+	}
+
+	s.actionsOnce.Do(func() {
+		s.On(s.actions.handle)
+	})
+}
+
+// ActionInstance returns the Action of the instance with given context, if created.
+func (s *StreamDeck) ActionInstance(context string) (Action, bool) {
+	return s.actions.get(context)
+}
+
+// GenerateManifest returns a copy of base with the actions registered with a description.
+func (s *StreamDeck) GenerateManifest(base Manifest) (*Manifest, error) {
+	s.actions.mu.Lock()
+	manifests := append([]ManifestAction(nil), s.actions.manifests...)
+	s.actions.mu.Unlock()
+
+	return GenerateManifest(base, manifests...)
+}
+
+// WriteManifest generates the manifest from base and registered actions, and writes it to given path.
+func (s *StreamDeck) WriteManifest(path string, base Manifest) error {
+	m, err := s.GenerateManifest(base)
+	if err != nil {
+		return err
+	}
+
+	return m.WriteFile(path)
+}
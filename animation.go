@@ -0,0 +1,292 @@
+package sdk
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultFPS is the frame rate of an animation when none is given.
+	defaultFPS = 10
+
+	// defaultFrameBudget is the maximum number of frames per second sent for all animations.
+	defaultFrameBudget = 60
+)
+
+// Frame is a single image of a key animation.
+type Frame struct {
+	// Image to display, encoded as expected by SetImage.
+	Image string
+
+	// Delay is how long the frame stays displayed.
+	// Zero means the animation frame rate is used.
+	Delay time.Duration
+}
+
+// FrameFunc returns the frame to display at given index.
+// Returning false ends the animation.
+type FrameFunc func(index int) (Frame, bool)
+
+// AnimationOption describes a single animation option.
+type AnimationOption func(*Animation)
+
+// WithFPS sets the frame rate of the animation, used for frames without a Delay.
+func WithFPS(fps float64) AnimationOption {
+	return func(a *Animation) {
+		if fps > 0 {
+			a.interval = time.Duration(float64(time.Second) / fps)
+		}
+	}
+}
+
+// WithLoop makes the animation restart from its first frame once the last one is displayed.
+// It only applies to animations started with Animate.
+func WithLoop(loop bool) AnimationOption {
+	return func(a *Animation) {
+		a.loop = loop
+	}
+}
+
+// Animation plays frames on the key of an action instance.
+// It is paused when the instance disappears and resumed when it appears again.
+type Animation struct {
+	context  string
+	next     FrameFunc
+	interval time.Duration
+	loop     bool
+
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newAnimation(context string, opts ...AnimationOption) *Animation {
+	a := &Animation{
+		context:  context,
+		interval: time.Second / defaultFPS,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Stop the animation. The last displayed frame stays on the key.
+func (a *Animation) Stop() {
+	a.stopOnce.Do(func() { close(a.stop) })
+}
+
+// Done is closed when the animation is over, either stopped or because it has no more frames.
+func (a *Animation) Done() <-chan struct{} {
+	return a.done
+}
+
+// pause the animation until resumed.
+func (a *Animation) pause() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.paused {
+		a.paused = true
+		a.resume = make(chan struct{})
+	}
+}
+
+// unpause resumes a paused animation.
+func (a *Animation) unpause() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.paused {
+		a.paused = false
+		close(a.resume)
+	}
+}
+
+// wait blocks while the animation is paused. It returns false if the animation has been stopped meanwhile.
+func (a *Animation) wait() bool {
+	a.mu.Lock()
+	paused, resume := a.paused, a.resume
+	a.mu.Unlock()
+
+	if !paused {
+		return true
+	}
+
+	select {
+	case <-resume:
+		return true
+	case <-a.stop:
+		return false
+	}
+}
+
+// run displays frames until the animation ends.
+func (a *Animation) run(s *StreamDeck) {
+	defer close(a.done)
+	defer s.animator.remove(a)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	// dropped is the last frame dropped over budget, displayed anyway when the animation ends
+	// so the key does not stay on a stale frame.
+	var dropped *Frame
+	for i := 0; ; i++ {
+		select {
+		case <-a.stop:
+			return
+		case <-timer.C:
+		}
+
+		if !a.wait() {
+			return
+		}
+
+		frame, ok := a.next(i)
+		if !ok {
+			if dropped != nil {
+				s.SetImage(a.context, dropped.Image)
+			}
+
+			return
+		}
+
+		// Frames over budget are dropped, the animation keeps its pace.
+		if s.animator.budget.allow() {
+			s.SetImage(a.context, frame.Image)
+			dropped = nil
+		} else {
+			dropped = &frame
+		}
+
+		delay := frame.Delay
+		if delay <= 0 {
+			delay = a.interval
+		}
+
+		timer.Reset(delay)
+	}
+}
+
+// animator keeps track of running animations, at most one per context.
+type animator struct {
+	mu         sync.Mutex
+	animations map[string]*Animation
+	budget     *tokenBucket
+	closed     bool
+}
+
+func newAnimator(budget int) *animator {
+	return &animator{
+		animations: make(map[string]*Animation),
+		budget:     newTokenBucket(float64(budget), budget),
+	}
+}
+
+// start runs given animation, replacing any animation running on the same context.
+func (an *animator) start(s *StreamDeck, a *Animation) {
+	an.mu.Lock()
+	defer an.mu.Unlock()
+
+	if an.closed {
+		a.Stop()
+		close(a.done)
+		return
+	}
+
+	if previous, ok := an.animations[a.context]; ok {
+		previous.Stop()
+	}
+
+	an.animations[a.context] = a
+	go a.run(s)
+}
+
+// remove forgets an ended animation.
+func (an *animator) remove(a *Animation) {
+	an.mu.Lock()
+	defer an.mu.Unlock()
+
+	if an.animations[a.context] == a {
+		delete(an.animations, a.context)
+	}
+}
+
+// stop the animation running on given context, if any.
+func (an *animator) stop(context string) {
+	an.mu.Lock()
+	defer an.mu.Unlock()
+
+	if a, ok := an.animations[context]; ok {
+		a.Stop()
+	}
+}
+
+// observe pauses and resumes animations following their instance visibility.
+func (an *animator) observe(event *ReceivedEvent) {
+	an.mu.Lock()
+	a, ok := an.animations[event.Context]
+	an.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	switch event.Event {
+	case WillDisappear:
+		a.pause()
+	case WillAppear:
+		a.unpause()
+	}
+}
+
+// close stops all animations. Animations started afterwards are stopped immediately.
+func (an *animator) close() {
+	an.mu.Lock()
+	defer an.mu.Unlock()
+
+	an.closed = true
+	for _, a := range an.animations {
+		a.Stop()
+	}
+}
+
+// Animate plays given frames on the key of the action with given context.
+// Any animation already running on this context is stopped.
+func (s *StreamDeck) Animate(context string, frames []Frame, opts ...AnimationOption) *Animation {
+	a := newAnimation(context, opts...)
+	a.next = func(index int) (Frame, bool) {
+		if len(frames) == 0 || (!a.loop && index >= len(frames)) {
+			return Frame{}, false
+		}
+
+		return frames[index%len(frames)], true
+	}
+
+	s.animator.start(s, a)
+	return a
+}
+
+// AnimateFunc plays frames generated by fn on the key of the action with given context.
+// Any animation already running on this context is stopped.
+func (s *StreamDeck) AnimateFunc(context string, fn FrameFunc, opts ...AnimationOption) *Animation {
+	a := newAnimation(context, opts...)
+	a.next = fn
+
+	s.animator.start(s, a)
+	return a
+}
+
+// StopAnimation stops the animation running on the key of the action with given context.
+func (s *StreamDeck) StopAnimation(context string) {
+	s.animator.stop(context)
+}
@@ -0,0 +1,162 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// bus queues messages published by actions and tracks subscriptions by action instance.
+// Messages are dispatched in publication order by a single goroutine, so publishing never blocks,
+// and only reach the handlers registered with OnMessage.
+type bus struct {
+	handlers *handlerRegistry
+
+	mu            sync.Mutex
+	queue         []*ReceivedEvent
+	notify        chan struct{}
+	subscriptions map[string][]func() // unsubscribe functions by subscriber context
+}
+
+func newBus() *bus {
+	return &bus{
+		handlers:      newHandlerRegistry(),
+		notify:        make(chan struct{}, 1),
+		subscriptions: make(map[string][]func()),
+	}
+}
+
+// publish queues given message event.
+func (b *bus) publish(event *ReceivedEvent) {
+	b.mu.Lock()
+	b.queue = append(b.queue, event)
+	b.mu.Unlock()
+
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pump dispatches queued messages until ctx is done.
+func (b *bus) pump(ctx context.Context, dispatch func(event *ReceivedEvent)) {
+	for {
+		b.mu.Lock()
+		queue := b.queue
+		b.queue = nil
+		b.mu.Unlock()
+
+		for _, event := range queue {
+			dispatch(event)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.notify:
+		}
+	}
+}
+
+// track records the unsubscribe function of a subscription of given context.
+func (b *bus) track(context string, unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscriptions[context] = append(b.subscriptions[context], unsubscribe)
+}
+
+// observe unsubscribes disappearing instances.
+func (b *bus) observe(event *ReceivedEvent) {
+	if event.Event != WillDisappear {
+		return
+	}
+
+	b.mu.Lock()
+	unsubscribes := b.subscriptions[event.Context]
+	delete(b.subscriptions, event.Context)
+	b.mu.Unlock()
+
+	for _, unsubscribe := range unsubscribes {
+		unsubscribe()
+	}
+}
+
+// PublishMessage sends message to the subscribers of its type, through the same dispatch as incoming events.
+// sender is the context of the publishing action instance, or empty for the plugin itself.
+// Messages of a same sender are delivered in order when ordered dispatch is enabled.
+// Messages have no context: subscriber errors are reported without alerting the sender.
+func (s *StreamDeck) PublishMessage(sender string, message interface{}) {
+	s.bus.publish(&ReceivedEvent{Event: BusMessage, Sender: sender, Message: message})
+}
+
+// OnMessage subscribes fn to messages published with PublishMessage.
+// fn must be a func(T), func(T) error or func(context.Context, T) error: it receives messages assignable to T.
+// subscriber is the context of the subscribing action instance: the subscription ends when it disappears.
+// An empty subscriber subscribes the plugin itself, until unsubscribe is called.
+func (s *StreamDeck) OnMessage(subscriber string, fn interface{}) (unsubscribe func(), err error) {
+	call, messageType, err := messageHandler(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	id := s.bus.handlers.add(func(ctx context.Context, event *ReceivedEvent) error {
+		if event.Message == nil || !reflect.TypeOf(event.Message).AssignableTo(messageType) {
+			return nil
+		}
+
+		return call(ctx, event.Message)
+	})
+
+	var once sync.Once
+	unsubscribe = func() {
+		once.Do(func() { s.bus.handlers.remove(id) })
+	}
+
+	if subscriber != "" {
+		s.bus.track(subscriber, unsubscribe)
+	}
+
+	return unsubscribe, nil
+}
+
+// messageHandler checks fn signature and returns a function calling it, with the message type it accepts.
+func messageHandler(fn interface{}) (func(ctx context.Context, message interface{}) error, reflect.Type, error) {
+	v := reflect.ValueOf(fn)
+	if !v.IsValid() || v.Kind() != reflect.Func || v.IsNil() {
+		return nil, nil, fmt.Errorf("message handler must be a function, got %T", fn)
+	}
+
+	t := v.Type()
+	withContext := t.NumIn() == 2 && t.In(0) == contextType
+	if !withContext && t.NumIn() != 1 {
+		return nil, nil, fmt.Errorf("message handler %s must accept a message, optionally preceded by a context", t)
+	}
+
+	if t.NumOut() > 1 || (t.NumOut() == 1 && t.Out(0) != errorType) || (withContext && t.NumOut() != 1) {
+		return nil, nil, fmt.Errorf("message handler %s must return nothing or an error", t)
+	}
+
+	messageType := t.In(t.NumIn() - 1)
+	call := func(ctx context.Context, message interface{}) error {
+		in := []reflect.Value{reflect.ValueOf(message)}
+		if withContext {
+			in = []reflect.Value{reflect.ValueOf(ctx), in[0]}
+		}
+
+		out := v.Call(in)
+		if len(out) == 1 && !out[0].IsNil() {
+			return out[0].Interface().(error)
+		}
+
+		return nil
+	}
+
+	return call, messageType, nil
+}
@@ -0,0 +1,228 @@
+package sdk
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultSequenceWithin is the delay within which all keys of a Sequence must be pressed.
+const defaultSequenceWithin = time.Second
+
+// Chord is matched when Press is pressed while all Hold keys are held down.
+type Chord struct {
+	// Name of the chord, set as Pattern of the chordTriggered event.
+	Name string
+
+	// Device restricts the chord to the device with this id. Empty means any device.
+	Device string
+
+	// Hold are the keys which must be held down.
+	Hold []Coordinates
+
+	// Press is the key completing the chord.
+	Press Coordinates
+
+	// Suppress prevents handlers from receiving keyDown and keyUp of the Press key when the chord is matched.
+	Suppress bool
+}
+
+// Sequence is matched when Keys are pressed in order on the same device.
+type Sequence struct {
+	// Name of the sequence, set as Pattern of the sequenceTriggered event.
+	Name string
+
+	// Device restricts the sequence to the device with this id. Empty means any device.
+	Device string
+
+	// Keys to press in order.
+	Keys []Coordinates
+
+	// Within is the maximum delay between the first and the last key press. Defaults to 1s.
+	Within time.Duration
+
+	// Suppress prevents handlers from receiving keyDown and keyUp of the last key when the sequence is matched.
+	Suppress bool
+}
+
+// keyPress is a key pressed on a device.
+type keyPress struct {
+	coordinates Coordinates
+	at          time.Time
+}
+
+// deviceKeys is the state of the keys of a device.
+type deviceKeys struct {
+	held    map[Coordinates]string // context of held keys
+	history []keyPress
+}
+
+// ChordDetector matches chords and sequences of keys pressed on a device, across action instances,
+// and dispatches chordTriggered and sequenceTriggered events.
+type ChordDetector struct {
+	unregister func()
+
+	mu         sync.Mutex
+	chords     []Chord
+	sequences  []Sequence
+	devices    map[string]*deviceKeys
+	suppressed map[string]bool // contexts whose next keyUp is suppressed
+	history    int             // number of presses to remember
+}
+
+// NewChordDetector creates a ChordDetector watching all incoming key events.
+func (s *StreamDeck) NewChordDetector() *ChordDetector {
+	d := &ChordDetector{
+		devices:    make(map[string]*deviceKeys),
+		suppressed: make(map[string]bool),
+	}
+
+	d.unregister = s.addInterceptor(d.intercept)
+	return d
+}
+
+// Close unregisters the ChordDetector, it stops watching key events.
+func (d *ChordDetector) Close() {
+	d.unregister()
+}
+
+// AddChord registers a chord.
+func (d *ChordDetector) AddChord(c Chord) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.chords = append(d.chords, c)
+}
+
+// AddSequence registers a sequence.
+func (d *ChordDetector) AddSequence(q Sequence) {
+	if q.Within <= 0 {
+		q.Within = defaultSequenceWithin
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.sequences = append(d.sequences, q)
+	if len(q.Keys) > d.history {
+		d.history = len(q.Keys)
+	}
+}
+
+// intercept implements interceptor.
+func (d *ChordDetector) intercept(event *ReceivedEvent) (bool, []*ReceivedEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch {
+	case event.Event == DeviceDidDisconnect:
+		delete(d.devices, event.Device)
+	case event.Payload == nil:
+	case event.Event == WillDisappear:
+		// The key may be held while its instance disappears, its keyUp would never come.
+		d.release(event)
+		delete(d.suppressed, event.Context)
+	case event.Event == KeyUp:
+		// Keys held by the context are released whatever sends the keyUp, so they never stay held.
+		d.release(event)
+		if !event.Payload.IsInMultiAction && d.suppressed[event.Context] {
+			delete(d.suppressed, event.Context)
+			return true, nil
+		}
+	case event.Event == KeyDown && !event.Payload.IsInMultiAction:
+		return d.keyDown(event)
+	}
+
+	return false, nil
+}
+
+// release forgets the keys held by the context of given event. d.mu must be held.
+func (d *ChordDetector) release(event *ReceivedEvent) {
+	keys, ok := d.devices[event.Device]
+	if !ok {
+		return
+	}
+
+	for coordinates, context := range keys.held {
+		if context == event.Context {
+			delete(keys.held, coordinates)
+		}
+	}
+}
+
+// keyDown records a key press and matches patterns it completes. d.mu must be held.
+func (d *ChordDetector) keyDown(event *ReceivedEvent) (bool, []*ReceivedEvent) {
+	keys, ok := d.devices[event.Device]
+	if !ok {
+		keys = &deviceKeys{held: make(map[Coordinates]string)}
+		d.devices[event.Device] = keys
+	}
+
+	pressed := event.Payload.Coordinates
+	now := time.Now()
+	keys.history = append(keys.history, keyPress{coordinates: pressed, at: now})
+	if len(keys.history) > d.history {
+		keys.history = keys.history[len(keys.history)-d.history:]
+	}
+
+	defer func() { keys.held[pressed] = event.Context }()
+
+	for _, c := range d.chords {
+		if (c.Device == "" || c.Device == event.Device) && c.Press == pressed && allHeld(keys.held, c.Hold) {
+			keys.history = nil
+			return d.matched(event, ChordTriggered, c.Name, c.Suppress)
+		}
+	}
+
+	for _, q := range d.sequences {
+		if (q.Device == "" || q.Device == event.Device) && completes(keys.history, q, now) {
+			keys.history = nil
+			return d.matched(event, SequenceTriggered, q.Name, q.Suppress)
+		}
+	}
+
+	return false, nil
+}
+
+// matched returns the synthetic event of a matched pattern. d.mu must be held.
+func (d *ChordDetector) matched(event *ReceivedEvent, name EventName, pattern string, suppress bool) (bool, []*ReceivedEvent) {
+	if suppress {
+		d.suppressed[event.Context] = true
+	}
+
+	synthetic := *event
+	synthetic.Event = name
+	synthetic.Pattern = pattern
+	return suppress, []*ReceivedEvent{&synthetic}
+}
+
+// allHeld reports whether all keys are held.
+func allHeld(held map[Coordinates]string, keys []Coordinates) bool {
+	for _, k := range keys {
+		if _, ok := held[k]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// completes reports whether the last presses of history match the sequence.
+func completes(history []keyPress, q Sequence, now time.Time) bool {
+	n := len(q.Keys)
+	if n == 0 || len(history) < n {
+		return false
+	}
+
+	last := history[len(history)-n:]
+	if now.Sub(last[0].at) > q.Within {
+		return false
+	}
+
+	for i, k := range q.Keys {
+		if last[i].coordinates != k {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,296 @@
+// Command streamdeck-gen generates Go code from the manifest.json of a Stream Deck plugin:
+// constants for action UUIDs, settings structs from the actions Settings section,
+// and handler interfaces matching the controllers of each action.
+//
+// The Settings section is specific to this command, and ignored by the Stream Deck application.
+// It lists the settings of an action, each with a Name, a JSON Type (string, number, integer,
+// boolean, array or object), an Items type for arrays and an optional Description:
+//
+//	"Settings": [{"Name": "device_id", "Type": "string", "Description": "Audio device."}]
+//
+// It is meant to be used with go generate:
+//
+//	//go:generate go run github.com/SkYNewZ/streamdeck-sdk/cmd/streamdeck-gen -manifest manifest.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+
+	sdk "github.com/SkYNewZ/streamdeck-sdk"
+)
+
+var (
+	manifestPath = flag.String("manifest", "manifest.json", "Path of the plugin manifest")
+	output       = flag.String("o", "actions_gen.go", "Path of the generated file")
+	packageName  = flag.String("package", os.Getenv("GOPACKAGE"), "Package of the generated file, defaults to the package running go generate")
+)
+
+// handlers lists the sdk handler interfaces implemented by actions, by controller.
+var handlers = map[sdk.Controller][]string{
+	sdk.KeyPad:  {"KeyDownHandler", "KeyUpHandler"},
+	sdk.Encoder: {"DialRotateHandler", "DialDownHandler", "DialUpHandler", "TouchTapHandler"},
+}
+
+// labels names controllers as in the manifest.
+var labels = map[sdk.Controller]string{
+	sdk.KeyPad:  "Keypad",
+	sdk.Encoder: "Encoder",
+}
+
+// initialisms are words written in upper case in identifiers.
+var initialisms = map[string]bool{
+	"API": true, "HTTP": true, "ID": true, "IP": true, "JSON": true, "URL": true, "UUID": true,
+}
+
+// settingTypes maps the JSON types of settings to Go types.
+var settingTypes = map[string]string{
+	"string":  "string",
+	"number":  "float64",
+	"integer": "int",
+	"boolean": "bool",
+	"array":   "[]interface{}",
+	"object":  "map[string]interface{}",
+}
+
+// manifest is the part of manifest.json the command reads.
+type manifest struct {
+	Actions []manifestAction `json:"Actions"`
+}
+
+// manifestAction is an action of the manifest, with its settings schema.
+type manifestAction struct {
+	sdk.ManifestAction
+	Settings []manifestSetting `json:"Settings"`
+}
+
+// manifestSetting describes a setting of an action.
+type manifestSetting struct {
+	Name        string `json:"Name"`
+	Type        string `json:"Type"`
+	Items       string `json:"Items"`
+	Description string `json:"Description"`
+}
+
+// goType returns the Go type of the setting.
+func (s manifestSetting) goType() (string, error) {
+	t, ok := settingTypes[s.Type]
+	if !ok {
+		return "", fmt.Errorf("%w: setting %q has unknown type %q", sdk.ErrInvalidManifest, s.Name, s.Type)
+	}
+
+	if s.Type != "array" || s.Items == "" {
+		return t, nil
+	}
+
+	items, ok := settingTypes[s.Items]
+	if !ok {
+		return "", fmt.Errorf("%w: setting %q has unknown items type %q", sdk.ErrInvalidManifest, s.Name, s.Items)
+	}
+
+	return "[]" + items, nil
+}
+
+// loadManifest reads the manifest at given path.
+func loadManifest(path string) (*manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("cannot decode manifest %s: %w", path, err)
+	}
+
+	return &m, nil
+}
+
+// action is the template data of an action.
+type action struct {
+	Name        string
+	UUID        string
+	Controllers string
+	Handlers    []string
+	Settings    []setting
+}
+
+// setting is the template data of an action setting.
+type setting struct {
+	Name        string
+	Key         string
+	Type        string
+	Description string
+}
+
+var tmpl = template.Must(template.New("actions").Parse(`// Code generated by streamdeck-gen from {{ .Manifest }}. DO NOT EDIT.
+
+package {{ .Package }}
+
+{{ if .Actions }}import sdk "github.com/SkYNewZ/streamdeck-sdk"
+
+// Action UUIDs.
+const (
+{{- range .Actions }}
+	{{ .Name }}UUID = "{{ .UUID }}"
+{{- end }}
+)
+{{ range .Actions }}
+{{- if .Settings }}
+// {{ .Name }}Settings are the settings of the {{ .Name }} action.
+type {{ .Name }}Settings struct {
+{{- range .Settings }}
+	{{- if .Description }}
+	// {{ .Description }}
+	{{- end }}
+	{{ .Name }} {{ .Type }} ` + "`" + `json:"{{ .Key }},omitempty"` + "`" + `
+{{- end }}
+}
+{{ end }}
+// {{ .Name }}Action handles instances of the {{ .Name }} action, on {{ .Controllers }} controllers.
+type {{ .Name }}Action interface {
+	sdk.WillAppearHandler
+	sdk.WillDisappearHandler
+{{- range .Handlers }}
+	sdk.{{ . }}
+{{- end }}
+}
+
+// Register{{ .Name }} registers the factory creating the {{ .Name }} action of each instance.
+func Register{{ .Name }}(s *sdk.StreamDeck, factory func(context string) {{ .Name }}Action, opts ...sdk.ActionOption) {
+	s.RegisterAction({{ .Name }}UUID, func(context string) sdk.Action { return factory(context) }, opts...)
+}
+{{ end }}{{ end }}`))
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("streamdeck-gen: ")
+	flag.Parse()
+
+	pkg := *packageName
+	if pkg == "" {
+		pkg = "main"
+	}
+
+	m, err := loadManifest(*manifestPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := generate(m, *manifestPath, pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the formatted Go source for given manifest.
+func generate(m *manifest, path string, pkg string) ([]byte, error) {
+	names := make(map[string]bool, len(m.Actions))
+	actions := make([]action, 0, len(m.Actions))
+	for _, a := range m.Actions {
+		if a.UUID == "" {
+			return nil, fmt.Errorf("%w: action %q: missing UUID", sdk.ErrInvalidManifest, a.Name)
+		}
+
+		name := identifier(a.UUID[strings.LastIndex(a.UUID, ".")+1:])
+		for base, i := name, 2; names[name]; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+
+		names[name] = true
+		data := action{Name: name, UUID: a.UUID}
+
+		var controllers []string
+		for _, c := range []sdk.Controller{sdk.KeyPad, sdk.Encoder} {
+			if a.Supports(c) {
+				controllers = append(controllers, labels[c])
+				data.Handlers = append(data.Handlers, handlers[c]...)
+			}
+		}
+
+		data.Controllers = strings.Join(controllers, " and ")
+
+		fields := make(map[string]string, len(a.Settings))
+		for _, s := range a.Settings {
+			if s.Name == "" {
+				return nil, fmt.Errorf("%w: action %q: setting without name", sdk.ErrInvalidManifest, a.UUID)
+			}
+
+			t, err := s.goType()
+			if err != nil {
+				return nil, fmt.Errorf("action %q: %w", a.UUID, err)
+			}
+
+			field := identifier(s.Name)
+			if other, ok := fields[field]; ok {
+				return nil, fmt.Errorf("%w: action %q: settings %q and %q both map to field %s",
+					sdk.ErrInvalidManifest, a.UUID, other, s.Name, field)
+			}
+
+			fields[field] = s.Name
+			data.Settings = append(data.Settings, setting{
+				Name:        field,
+				Key:         s.Name,
+				Type:        t,
+				Description: strings.Join(strings.Fields(s.Description), " "),
+			})
+		}
+
+		actions = append(actions, data)
+	}
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, struct {
+		Manifest string
+		Package  string
+		Actions  []action
+	}{path, pkg, actions})
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("cannot format generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+// identifier converts given name to an exported Go identifier,
+// e.g. toggle-mute becomes ToggleMute and device_id becomes DeviceID.
+func identifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	if b.Len() == 0 || unicode.IsDigit([]rune(b.String())[0]) {
+		return "X" + b.String()
+	}
+
+	return b.String()
+}
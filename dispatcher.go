@@ -0,0 +1,266 @@
+package sdk
+
+import (
+	"context"
+	"hash/fnv"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultQueueLength is the number of events waiting to be handled by each dispatch queue.
+const defaultQueueLength = 64
+
+// OverflowPolicy decides what happens to an incoming event when the dispatch queue is full.
+type OverflowPolicy uint8
+
+const (
+	// OverflowBlock waits for room in the queue, slowing down the reading of incoming events.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropOldest drops the oldest queued event to make room for the incoming one.
+	OverflowDropOldest
+
+	// OverflowDropNewest drops the incoming event.
+	OverflowDropNewest
+)
+
+// DispatchStats describes the activity of event handling.
+type DispatchStats struct {
+	// Queued is the number of events waiting to be handled.
+	Queued int
+
+	// Running is the number of events being handled.
+	Running int
+
+	// Dropped is the number of events dropped because of the OverflowPolicy.
+	Dropped uint64
+}
+
+// dispatcher runs handlers on incoming events.
+type dispatcher interface {
+	// dispatch schedules given event to be handled with given context.
+	dispatch(ctx context.Context, event *ReceivedEvent)
+
+	// stats returns the current activity.
+	stats() DispatchStats
+
+	// close stops accepting events.
+	close()
+}
+
+// handleFunc runs handlers on an event.
+type handleFunc func(ctx context.Context, event *ReceivedEvent)
+
+// delivery is an event to handle with its context.
+type delivery struct {
+	ctx   context.Context
+	event *ReceivedEvent
+}
+
+// concurrentDispatcher handles each event in its own goroutine.
+type concurrentDispatcher struct {
+	handle  handleFunc
+	running int64
+}
+
+func (d *concurrentDispatcher) dispatch(ctx context.Context, event *ReceivedEvent) {
+	atomic.AddInt64(&d.running, 1)
+	go func() {
+		defer atomic.AddInt64(&d.running, -1)
+		d.handle(ctx, event)
+	}()
+}
+
+func (d *concurrentDispatcher) stats() DispatchStats {
+	return DispatchStats{Running: int(atomic.LoadInt64(&d.running))}
+}
+
+func (d *concurrentDispatcher) close() {}
+
+// eventQueue is a bounded FIFO queue of events.
+type eventQueue struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	events   []delivery
+	limit    int
+	policy   OverflowPolicy
+	dropped  uint64
+	closed   bool
+}
+
+func newEventQueue(limit int, policy OverflowPolicy) *eventQueue {
+	q := &eventQueue{limit: limit, policy: policy}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds given event, applying the overflow policy when the queue is full.
+func (q *eventQueue) push(d delivery) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && len(q.events) >= q.limit {
+		switch q.policy {
+		case OverflowDropOldest:
+			q.events[0] = delivery{}
+			q.events = q.events[1:]
+			q.dropped++
+		case OverflowDropNewest:
+			q.dropped++
+			return
+		default:
+			q.notFull.Wait()
+		}
+	}
+
+	if q.closed {
+		return
+	}
+
+	q.events = append(q.events, d)
+	q.notEmpty.Signal()
+}
+
+// pop waits for an event. It returns false once the queue is closed.
+func (q *eventQueue) pop() (delivery, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && len(q.events) == 0 {
+		q.notEmpty.Wait()
+	}
+
+	if q.closed {
+		return delivery{}, false
+	}
+
+	d := q.events[0]
+	q.events[0] = delivery{}
+	q.events = q.events[1:]
+	q.notFull.Signal()
+	return d, true
+}
+
+// len returns the number of queued events and the number of dropped ones.
+func (q *eventQueue) len() (int, uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.events), q.dropped
+}
+
+// close wakes up waiting callers, queued events are dropped.
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.events = nil
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+}
+
+// poolDispatcher handles events with a fixed number of workers reading bounded queues.
+// When ordered, each worker has its own queue and events of a same context always go to the same queue,
+// so they are handled sequentially in order. Otherwise, all workers share a single queue.
+type poolDispatcher struct {
+	handle  handleFunc
+	queues  []*eventQueue
+	running int64
+}
+
+func newPoolDispatcher(workers int, length int, policy OverflowPolicy, ordered bool, handle handleFunc) *poolDispatcher {
+	d := &poolDispatcher{handle: handle}
+
+	if ordered {
+		d.queues = make([]*eventQueue, workers)
+		for i := range d.queues {
+			d.queues[i] = newEventQueue(length, policy)
+			go d.work(d.queues[i])
+		}
+
+		return d
+	}
+
+	d.queues = []*eventQueue{newEventQueue(length, policy)}
+	for i := 0; i < workers; i++ {
+		go d.work(d.queues[0])
+	}
+
+	return d
+}
+
+// work handles events of given queue until it is closed.
+func (d *poolDispatcher) work(q *eventQueue) {
+	for {
+		next, ok := q.pop()
+		if !ok {
+			return
+		}
+
+		atomic.AddInt64(&d.running, 1)
+		d.handle(next.ctx, next.event)
+		atomic.AddInt64(&d.running, -1)
+	}
+}
+
+func (d *poolDispatcher) dispatch(ctx context.Context, event *ReceivedEvent) {
+	q := d.queues[0]
+	if len(d.queues) > 1 {
+		// Bus messages have no context: those of a same sender share a queue.
+		key := event.Context
+		if key == "" {
+			key = event.Sender
+		}
+
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(key))
+		q = d.queues[h.Sum32()%uint32(len(d.queues))]
+	}
+
+	q.push(delivery{ctx: ctx, event: event})
+}
+
+func (d *poolDispatcher) stats() DispatchStats {
+	stats := DispatchStats{Running: int(atomic.LoadInt64(&d.running))}
+	for _, q := range d.queues {
+		n, dropped := q.len()
+		stats.Queued += n
+		stats.Dropped += dropped
+	}
+
+	return stats
+}
+
+func (d *poolDispatcher) close() {
+	for _, q := range d.queues {
+		q.close()
+	}
+}
+
+// newDispatcher returns the dispatcher configured by options.
+func (s *StreamDeck) newDispatcher() dispatcher {
+	workers := s.workers
+	if workers <= 0 {
+		if !s.ordered {
+			return &concurrentDispatcher{handle: s.handle}
+		}
+
+		workers = runtime.NumCPU()
+	}
+
+	length := s.queueLength
+	if length <= 0 {
+		length = defaultQueueLength
+	}
+
+	return newPoolDispatcher(workers, length, s.overflow, s.ordered, s.handle)
+}
+
+// DispatchStats returns the activity of event handling, such as the number of events waiting to be handled.
+func (s *StreamDeck) DispatchStats() DispatchStats {
+	return s.dispatcher.stats()
+}
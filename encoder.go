@@ -0,0 +1,272 @@
+package sdk
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultAccelerationWindow is the delay under which successive rotations are considered fast.
+const defaultAccelerationWindow = 50 * time.Millisecond
+
+// EncoderValueOptions configures an EncoderValue.
+type EncoderValueOptions struct {
+	// Min and Max bound the value.
+	Min, Max float64
+
+	// Default is the initial value, restored when the dial is pushed.
+	Default float64
+
+	// Step is added for each rotation tick. Defaults to 1.
+	Step float64
+
+	// PressedStep is added for each rotation tick while the dial is pressed. Defaults to Step.
+	PressedStep float64
+
+	// Wrap makes the value go back to Min when going past Max, and the other way around, instead of being clamped.
+	// The range is inclusive: one Step past Max gives Min, and one Step before Min gives Max.
+	Wrap bool
+
+	// Acceleration multiplies the step of fast rotations. Defaults to 1, no acceleration.
+	Acceleration float64
+
+	// AccelerationWindow is the delay under which successive rotations are fast. Defaults to 50ms.
+	AccelerationWindow time.Duration
+
+	// KeepOnPush disables resetting the value to Default when the dial is pushed.
+	KeepOnPush bool
+
+	// SettingsKey is the action setting the value is persisted to and restored from. Empty disables persistence.
+	SettingsKey string
+
+	// Format returns the text displayed for a value. Defaults to the shortest decimal representation.
+	Format func(value float64) string
+
+	// Render returns the feedback displayed for a value.
+	// Defaults to an IndicatorFeedback showing the formatted value and an indicator within Min and Max.
+	// Returning nil displays nothing.
+	Render func(value float64) FeedbackPayload
+
+	// OnChange is called with the new value each time it changes.
+	OnChange func(value float64)
+
+	// OnTap is called when the touch display is tapped.
+	OnTap func(value float64, hold bool)
+}
+
+// EncoderValue binds the dial of an encoder action instance to a bounded numeric value.
+// It handles dialRotate, dialDown and touchTap events of its context, persists the value
+// to settings and displays it with setFeedback.
+type EncoderValue struct {
+	s          *StreamDeck
+	unregister func()
+	context    string
+	opts       EncoderValueOptions
+
+	mu     sync.Mutex
+	value  float64
+	rotate time.Time // last rotation
+}
+
+// NewEncoderValue creates an EncoderValue for the action with given context and registers it as a handler.
+// The value is restored from the settings of the action when available.
+func (s *StreamDeck) NewEncoderValue(context string, opts EncoderValueOptions) *EncoderValue {
+	if opts.Step == 0 {
+		opts.Step = 1
+	}
+
+	if opts.PressedStep == 0 {
+		opts.PressedStep = opts.Step
+	}
+
+	if opts.Acceleration < 1 {
+		opts.Acceleration = 1
+	}
+
+	if opts.AccelerationWindow <= 0 {
+		opts.AccelerationWindow = defaultAccelerationWindow
+	}
+
+	if opts.Format == nil {
+		opts.Format = func(value float64) string {
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+	}
+
+	if opts.Render == nil {
+		opts.Render = func(value float64) FeedbackPayload {
+			return &IndicatorFeedback{
+				Value:     &TextItem{Value: opts.Format(value)},
+				Indicator: &BarItem{Value: value, Range: &Range{Min: opts.Min, Max: opts.Max}},
+			}
+		}
+	}
+
+	v := &EncoderValue{
+		s:       s,
+		context: context,
+		opts:    opts,
+		value:   opts.Default,
+	}
+
+	v.restore(s.Settings(context))
+	v.unregister = s.On(contextless(v.Handle))
+	return v
+}
+
+// Value returns the current value.
+func (v *EncoderValue) Value() float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.value
+}
+
+// Set changes the value, bounded to Min and Max.
+func (v *EncoderValue) Set(value float64) {
+	v.mu.Lock()
+	changed := v.set(value)
+	value = v.value
+	v.mu.Unlock()
+
+	if changed {
+		v.changed(value)
+	}
+}
+
+// Close unregisters the EncoderValue handler, it stops handling events.
+func (v *EncoderValue) Close() {
+	v.unregister()
+}
+
+// Handle implements Handler.
+func (v *EncoderValue) Handle(event *ReceivedEvent) error {
+	if event.Context != v.context || event.Payload == nil {
+		return nil
+	}
+
+	switch event.Event {
+	case WillAppear:
+		v.restore(event.Payload.Settings)
+		v.render(v.Value())
+	case DidReceiveSettings:
+		if v.restore(event.Payload.Settings) {
+			v.render(v.Value())
+		}
+	case DialRotate:
+		v.rotated(event.Payload.Ticks, event.Payload.Pressed)
+	case DialDown:
+		if !v.opts.KeepOnPush {
+			v.Set(v.opts.Default)
+		}
+	case TouchTap:
+		if v.opts.OnTap != nil {
+			v.opts.OnTap(v.Value(), event.Payload.Hold)
+		}
+	}
+
+	return nil
+}
+
+// rotated applies a rotation of given ticks.
+func (v *EncoderValue) rotated(ticks int, pressed bool) {
+	step := v.opts.Step
+	if pressed {
+		step = v.opts.PressedStep
+	}
+
+	v.mu.Lock()
+	now := time.Now()
+	if now.Sub(v.rotate) < v.opts.AccelerationWindow {
+		step *= v.opts.Acceleration
+	}
+
+	v.rotate = now
+	changed := v.set(v.value + float64(ticks)*step)
+	value := v.value
+	v.mu.Unlock()
+
+	if changed {
+		v.changed(value)
+	}
+}
+
+// set bounds and stores value, reporting whether it changed. v.mu must be held.
+func (v *EncoderValue) set(value float64) bool {
+	lo, hi := v.opts.Min, v.opts.Max
+	switch {
+	case hi <= lo:
+	case v.opts.Wrap && (value < lo || value > hi):
+		// Wrapping spans the inclusive range plus one step, from Max to Min.
+		period := hi - lo + math.Abs(v.opts.Step)
+		wrapped := lo + math.Mod(value-lo, period)
+		if wrapped < lo {
+			wrapped += period
+		}
+
+		// Values between Max and Min once wrapped land on the bound reached first.
+		switch {
+		case wrapped <= hi:
+		case value > hi:
+			wrapped = lo
+		default:
+			wrapped = hi
+		}
+
+		value = wrapped
+	case value < lo:
+		value = lo
+	case value > hi:
+		value = hi
+	}
+
+	if value == v.value {
+		return false
+	}
+
+	v.value = value
+	return true
+}
+
+// restore reads the value from given settings, reporting whether it changed.
+func (v *EncoderValue) restore(settings map[string]interface{}) bool {
+	if v.opts.SettingsKey == "" {
+		return false
+	}
+
+	value, ok := settings[v.opts.SettingsKey].(float64)
+	if !ok {
+		return false
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.set(value)
+}
+
+// changed persists and displays a new value, then notifies the callback.
+func (v *EncoderValue) changed(value float64) {
+	if v.opts.SettingsKey != "" {
+		v.s.MergeSettings(v.context, map[string]interface{}{v.opts.SettingsKey: value})
+	}
+
+	v.render(value)
+
+	if v.opts.OnChange != nil {
+		v.opts.OnChange(value)
+	}
+}
+
+// render displays given value on the touch display.
+func (v *EncoderValue) render(value float64) {
+	feedback := v.opts.Render(value)
+	if feedback == nil {
+		return
+	}
+
+	if err := v.s.SendFeedback(v.context, feedback); err != nil {
+		v.s.Logf("[ERROR] encoder value for context [%s]: %v", v.context, err)
+	}
+}
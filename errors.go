@@ -0,0 +1,129 @@
+package sdk
+
+import (
+	"errors"
+	"strings"
+)
+
+// maxHandlerAttempts is the maximum number of times a handler is called for an event when retried.
+const maxHandlerAttempts = 3
+
+// ErrStopPropagation can be returned by a handler to prevent the following handlers from receiving the event.
+// It is not reported as an error.
+var ErrStopPropagation = errors.New("stop propagation")
+
+// ErrSilent can be wrapped by handler errors which must be logged without alerting the user.
+var ErrSilent = errors.New("silent error")
+
+// UserVisibleError is a handler error the user must be told about, even by QuietErrorHandler.
+type UserVisibleError struct {
+	Err error
+}
+
+// Error implements error.
+func (e *UserVisibleError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *UserVisibleError) Unwrap() error {
+	return e.Err
+}
+
+// HandlerErrors aggregates the errors returned by several handlers for the same event.
+type HandlerErrors []error
+
+// Error implements error.
+func (e HandlerErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// ErrorAction is what happens when a handler returns an error.
+type ErrorAction uint8
+
+const (
+	// ErrorAlert logs the error and shows an alert on the action once all handlers ran.
+	ErrorAlert ErrorAction = iota
+
+	// ErrorLog only logs the error.
+	ErrorLog
+
+	// ErrorIgnore neither logs nor alerts.
+	ErrorIgnore
+
+	// ErrorStop logs the error and skips the following handlers.
+	// The action is alerted only if an earlier handler error requested it.
+	ErrorStop
+
+	// ErrorRetry calls the handler again, up to 3 attempts.
+	// The error of the last attempt is then handled as ErrorAlert.
+	ErrorRetry
+
+	// ErrorStopAlert logs the error, shows an alert on the action and skips the following handlers.
+	ErrorStopAlert
+)
+
+// ErrorHandler decides what happens when a handler returns err for event.
+// attempt is the number of times the handler has been called for this event, starting at 1.
+type ErrorHandler func(event *ReceivedEvent, err error, attempt int) ErrorAction
+
+// DefaultErrorHandler alerts on errors, except for ErrSilent errors
+// and errors of events without action instance, such as deviceDidConnect, which are only logged.
+func DefaultErrorHandler(event *ReceivedEvent, err error, _ int) ErrorAction {
+	if errors.Is(err, ErrSilent) || event.Context == "" {
+		return ErrorLog
+	}
+
+	return ErrorAlert
+}
+
+// QuietErrorHandler only alerts on UserVisibleError errors, others are logged.
+func QuietErrorHandler(event *ReceivedEvent, err error, _ int) ErrorAction {
+	var visible *UserVisibleError
+	if errors.As(err, &visible) && event.Context != "" {
+		return ErrorAlert
+	}
+
+	return ErrorLog
+}
+
+// report logs errors of an event and alerts its action if requested.
+func (s *StreamDeck) report(event *ReceivedEvent, errs []error, alert bool) {
+	if len(errs) == 0 {
+		return
+	}
+
+	var err error = HandlerErrors(errs)
+	if len(errs) == 1 {
+		err = errs[0]
+	}
+
+	s.Logf("[ERROR] event [%s] action [%s]: %v", event.Event, event.Action, err)
+	if alert && event.Context != "" {
+		s.Alert(event.Context)
+	}
+}
+
+// reportError handles err returned for event outside the handler chain, following the error handler.
+// It returns true when the failed call must be retried.
+func (s *StreamDeck) reportError(event *ReceivedEvent, err error, attempt int) bool {
+	action := s.errorHandler(event, err, attempt)
+	if action == ErrorRetry && attempt < maxHandlerAttempts {
+		return true
+	}
+
+	switch action {
+	case ErrorIgnore:
+	case ErrorLog, ErrorStop:
+		s.report(event, []error{err}, false)
+	default: // ErrorAlert, ErrorStopAlert and an exhausted ErrorRetry
+		s.report(event, []error{err}, true)
+	}
+
+	return false
+}
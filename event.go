@@ -83,6 +83,20 @@ const (
 	// SendToPlugin (from the Property Inspector) Send a payload to the plugin.
 	SendToPlugin EventName = "sendToPlugin"
 
+	// [Synthetic Events] dispatched by this SDK
+
+	// ChordTriggered When a Chord registered on a ChordDetector is matched,
+	// the plugin will receive a chordTriggered event for the key completing it.
+	ChordTriggered EventName = "chordTriggered"
+
+	// SequenceTriggered When a Sequence registered on a ChordDetector is matched,
+	// the plugin will receive a sequenceTriggered event for the key completing it.
+	SequenceTriggered EventName = "sequenceTriggered"
+
+	// BusMessage When an action publishes a message with PublishMessage,
+	// handlers registered with OnMessage will receive a busMessage event, unlike other handlers.
+	BusMessage EventName = "busMessage"
+
 	// [Events Sent] (https://docs.elgato.com/sdk/plugins/events-sent)
 
 	// SetSettings Save data persistently for the action's instance.
@@ -156,6 +170,15 @@ const (
 
 	// KESDSDKDeviceTypeCorsairGKeys Device type: Corsair G-Keys.
 	KESDSDKDeviceTypeCorsairGKeys
+
+	// KESDSDKDeviceTypeStreamDeckPedal Device type: Stream Deck Pedal.
+	KESDSDKDeviceTypeStreamDeckPedal
+
+	// KESDSDKDeviceTypeCorsairVoyager Device type: Corsair Voyager laptop.
+	KESDSDKDeviceTypeCorsairVoyager
+
+	// KESDSDKDeviceTypeStreamDeckPlus Device type: Stream Deck +.
+	KESDSDKDeviceTypeStreamDeckPlus
 )
 
 // Controller used with StreamDeck.
@@ -178,7 +201,7 @@ const (
 
 	// ValueLayout is a layout that displays a value on the Stream Deck + touch display.
 	// See [Value Layout](https://docs.elgato.com/sdk/plugins/layouts-sd+#value-layout-usda1)
-	ValueLayout = "$A0"
+	ValueLayout = "$A1"
 
 	// IndicatorLayout is a layout that displays an indicator on the Stream Deck + touch display.
 	// See [Indicator Layout](https://docs.elgato.com/sdk/plugins/layouts-sd+#indicator-layout-usdb1)
@@ -225,6 +248,25 @@ type ReceivedEvent struct {
 
 	// A json object containing context about received event
 	Payload *ReceivedEventPayload `json:"payload"`
+
+	// The name of the matched pattern. Used on synthetic events: chordTriggered, sequenceTriggered
+	Pattern string `json:"-"`
+
+	// The published message. Used on synthetic events: busMessage
+	Message interface{} `json:"-"`
+
+	// The context of the action instance publishing the message, empty for the plugin itself.
+	// Used on synthetic events: busMessage
+	Sender string `json:"-"`
+
+	// action is the registered Action instance the event is for, bound when received.
+	action Action
+}
+
+// Coordinates of a key on a device.
+type Coordinates struct {
+	Column uint8 `json:"column"`
+	Row    uint8 `json:"row"`
 }
 
 // ReceivedEventPayload describes a payload received from StreamDeck SDK.
@@ -237,10 +279,7 @@ type ReceivedEventPayload struct {
 	Controller Controller `json:"controller"`
 
 	// The coordinates of the action triggered.
-	Coordinates struct {
-		Column uint8 `json:"column"`
-		Row    uint8 `json:"row"`
-	} `json:"coordinates"`
+	Coordinates Coordinates `json:"coordinates"`
 
 	// The array which holds (x, y) coordinates as a position of tap inside of LCD slot associated with action.
 	// Used on events: touchTap
@@ -340,15 +379,16 @@ type SendEventPayload struct {
 	// Used on events: setTitle
 	Title string `json:"title,omitempty"`
 
-	// Specify where you want to display the title.
+	// Specify where you want to display the title or image.
+	// This is an optional parameter, if not specified it is displayed on hardware and software.
 	// Used on events: setTitle, setImage
-	Target Target `json:"target,omitempty"`
+	Target *Target `json:"target,omitempty"`
 
 	// A 0-based integer value representing the state of an action with multiple states.
-	// This is an optional parameter.
-	// If not specified, the title is set to all states.
+	// This is an optional parameter for setTitle and setImage.
+	// If not specified, the title or image is set to all states.
 	// Used on events: setTitle, setImage, setState
-	State uint8 `json:"state,omitempty"`
+	State *uint8 `json:"state,omitempty"`
 
 	// The image to display encoded in base64 with the image format declared in the mime type (PNG, JPEG, BMP, ...).
 	// svg is also supported.
@@ -2,103 +2,147 @@ package sdk
 
 // Alert sends an alert on the StreamDeck of action with given context.
 func (s *StreamDeck) Alert(context string) {
-	s.writeCh <- &SendEvent{Event: ShowAlert, Context: context}
+	s.send(&SendEvent{Event: ShowAlert, Context: context})
 }
 
 // OpenURL tell the Stream Deck application to open an URL in the default browser.
 func (s *StreamDeck) OpenURL(u string) {
-	s.writeCh <- &SendEvent{Event: OpenURL, Payload: &SendEventPayload{URL: u}}
+	s.send(&SendEvent{Event: OpenURL, Payload: &SendEventPayload{URL: u}})
+}
+
+// UpdateOption describes a single option of SetTitleWith and SetImageWith.
+type UpdateOption func(*SendEventPayload)
+
+// WithState only updates given state of an action supporting multiple states.
+// By default, all states are updated.
+func WithState(state uint8) UpdateOption {
+	return func(p *SendEventPayload) {
+		p.State = &state
+	}
+}
+
+// WithTarget only updates the title or image on given target.
+// By default, both hardware and software are updated.
+func WithTarget(target Target) UpdateOption {
+	return func(p *SendEventPayload) {
+		p.Target = &target
+	}
 }
 
 // SetTitle tell StreamDeck to dynamically change the title of action with given context.
 func (s *StreamDeck) SetTitle(context string, title string, target Target) {
-	s.writeCh <- &SendEvent{
+	s.SetTitleWith(context, title, WithTarget(target))
+}
+
+// SetTitleWith tell StreamDeck to dynamically change the title of action with given context, using given options.
+func (s *StreamDeck) SetTitleWith(context string, title string, opts ...UpdateOption) {
+	payload := &SendEventPayload{Title: title}
+	for _, opt := range opts {
+		opt(payload)
+	}
+
+	s.send(&SendEvent{
 		Event:   SetTitle,
 		Context: context,
-		Payload: &SendEventPayload{
-			Title:  title,
-			Target: target,
-			//State: 0, TODO: state is not supported yet
-		},
-	}
+		Payload: payload,
+	})
 }
 
 // ShowOK temporarily show an OK checkmark icon of action with given context.
 func (s *StreamDeck) ShowOK(context string) {
-	s.writeCh <- &SendEvent{Event: ShowOk, Context: context}
+	s.send(&SendEvent{Event: ShowOk, Context: context})
 }
 
 // SetState change the state of an action supporting multiple states.
 func (s *StreamDeck) SetState(context string, state uint8) {
-	s.writeCh <- &SendEvent{
+	s.send(&SendEvent{
 		Event:   SetState,
 		Context: context,
-		Payload: &SendEventPayload{State: state},
-	}
+		Payload: &SendEventPayload{State: &state},
+	})
 }
 
 // SetImage change the image of an action with given context.
 func (s *StreamDeck) SetImage(context string, image string) {
-	s.writeCh <- &SendEvent{
+	s.SetImageWith(context, image)
+}
+
+// SetImageWith change the image of an action with given context, using given options.
+func (s *StreamDeck) SetImageWith(context string, image string, opts ...UpdateOption) {
+	payload := &SendEventPayload{Image: image}
+	for _, opt := range opts {
+		opt(payload)
+	}
+
+	s.send(&SendEvent{
 		Event:   SetImage,
 		Context: context,
-		Payload: &SendEventPayload{Image: image},
-	}
+		Payload: payload,
+	})
 }
 
 // SetTriggerDescription change the trigger description of an action with given context.
 func (s *StreamDeck) SetTriggerDescription(context string, payload *SendEventSetTriggerDescriptionPayload) {
-	s.writeCh <- &SendEvent{
+	s.send(&SendEvent{
 		Event:   SetTriggerDescription,
 		Context: context,
 		Payload: payload,
-	}
+	})
 }
 
 // SetFeedback change the feedback of an action with given context.
+// Keys which are not items of the layout displayed by the action are reported in the logs.
 func (s *StreamDeck) SetFeedback(context string, payload *SendEventSetFeedbackPayload) {
-	s.writeCh <- &SendEvent{
+	if payload != nil {
+		if layout, unknown := s.layouts.unknownKeys(context, *payload); len(unknown) > 0 {
+			s.Logf("[WARN] setFeedback for context [%s]: layout [%s] has no items %v", context, layout, unknown)
+		}
+	}
+
+	s.send(&SendEvent{
 		Event:   SetFeedback,
 		Context: context,
 		Payload: payload,
-	}
+	})
 }
 
 // SetFeedbackLayout change the feedback layout of an action with given context.
 func (s *StreamDeck) SetFeedbackLayout(context string, layout string) {
-	s.writeCh <- &SendEvent{
+	s.layouts.set(context, layout)
+	s.send(&SendEvent{
 		Event:   SetFeedbackLayout,
 		Context: context,
 		Payload: &SendEventSetFeedbackLayoutPayload{Layout: layout},
-	}
+	})
 }
 
 // SetSettings change the settings of an action with given context.
 func (s *StreamDeck) SetSettings(context string, settings map[string]interface{}) {
-	s.writeCh <- &SendEvent{
+	s.instances.setSettings(context, settings)
+	s.send(&SendEvent{
 		Event:   SetSettings,
 		Context: context,
 		Payload: &settings,
-	}
+	})
 }
 
 // GetSettings get the settings of an action with given context.
 // Settings will be sent back to the plugin as a ReceivedEvent with the event name DidReceiveSettings.
 func (s *StreamDeck) GetSettings(context string) {
-	s.writeCh <- &SendEvent{Event: GetSettings, Context: context}
+	s.send(&SendEvent{Event: GetSettings, Context: context})
 }
 
 // SetGlobalSettings change the global settings of an action with given context.
 func (s *StreamDeck) SetGlobalSettings(context string, settings map[string]interface{}) {
-	s.writeCh <- &SendEvent{
+	s.send(&SendEvent{
 		Event:   SetGlobalSettings,
 		Context: context,
 		Payload: &settings,
-	}
+	})
 }
 
 // GetGlobalSettings get the global settings of an action with given context.
 // Global settings will be sent back to the plugin as a ReceivedEvent with the event name DidReceiveGlobalSettings.
 func (s *StreamDeck) GetGlobalSettings(context string) {
-	s.writeCh <- &SendEvent{Event: GetGlobalSettings, Context: context}
+	s.send(&SendEvent{Event: GetGlobalSettings, Context: context})
 }
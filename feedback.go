@@ -0,0 +1,419 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrInvalidFeedback is returned when a feedback does not match its layout.
+var ErrInvalidFeedback = errors.New("invalid feedback")
+
+// ItemType is the type of an item of a Stream Deck + touch display layout.
+type ItemType string
+
+const (
+	// ItemText displays a text.
+	ItemText ItemType = "text"
+
+	// ItemPixmap displays an image.
+	ItemPixmap ItemType = "pixmap"
+
+	// ItemBar displays a bar filled to a value.
+	ItemBar ItemType = "bar"
+
+	// ItemGBar displays a gradient bar with an indicator at a value.
+	ItemGBar ItemType = "gbar"
+)
+
+// builtinLayouts describes the items of each built-in layout.
+var builtinLayouts = map[string]map[string]ItemType{
+	IconLayout: {
+		"title": ItemText,
+		"icon":  ItemPixmap,
+	},
+	CanvasLayout: {
+		"title":       ItemText,
+		"full-canvas": ItemPixmap,
+		"canvas":      ItemPixmap,
+	},
+	ValueLayout: {
+		"title": ItemText,
+		"icon":  ItemPixmap,
+		"value": ItemText,
+	},
+	IndicatorLayout: {
+		"title":     ItemText,
+		"icon":      ItemPixmap,
+		"value":     ItemText,
+		"indicator": ItemBar,
+	},
+	GradientIndicatorLayout: {
+		"title":     ItemText,
+		"icon":      ItemPixmap,
+		"value":     ItemText,
+		"indicator": ItemGBar,
+	},
+	DoubleIndicatorLayout: {
+		"title":      ItemText,
+		"icon1":      ItemPixmap,
+		"icon2":      ItemPixmap,
+		"indicator1": ItemBar,
+		"indicator2": ItemBar,
+	},
+}
+
+// Font of a text item.
+type Font struct {
+	// Size of the font, in pixels.
+	Size int `json:"size,omitempty"`
+
+	// Weight of the font, from 100 to 1000.
+	Weight int `json:"weight,omitempty"`
+}
+
+// Range of a bar item.
+type Range struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// FeedbackItem is an item value sent with setFeedback.
+type FeedbackItem interface {
+	// ItemType returns the type of layout item this value applies to.
+	ItemType() ItemType
+}
+
+// TextItem updates a text item of a layout.
+type TextItem struct {
+	// The text to display.
+	Value string `json:"value,omitempty"`
+
+	// Clear sends an empty Value, clearing the text.
+	Clear bool `json:"-"`
+
+	// Color of the text.
+	Color string `json:"color,omitempty"`
+
+	// Alignment of the text: left, center or right.
+	Alignment string `json:"alignment,omitempty"`
+
+	// Font of the text.
+	Font *Font `json:"font,omitempty"`
+
+	// How overflowing text is handled: clip, ellipsis or fade.
+	TextOverflow string `json:"text-overflow,omitempty"`
+
+	// Background color or gradient of the item.
+	Background string `json:"background,omitempty"`
+
+	// Whether the item is displayed.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Opacity of the item, from 0 to 1.
+	Opacity *float64 `json:"opacity,omitempty"`
+}
+
+// ItemType implements FeedbackItem.
+func (*TextItem) ItemType() ItemType { return ItemText }
+
+// MarshalJSON implements json.Marshaler, sending an empty value when the text is cleared.
+func (t TextItem) MarshalJSON() ([]byte, error) {
+	type item TextItem
+	v := struct {
+		item
+		Value *string `json:"value,omitempty"`
+	}{item: item(t)}
+
+	if t.Value != "" || t.Clear {
+		v.Value = &t.Value
+	}
+
+	return json.Marshal(v)
+}
+
+// PixmapItem updates an image item of a layout.
+type PixmapItem struct {
+	// The image to display, as a path relative to the plugin folder or a base64 data URI.
+	Value string `json:"value,omitempty"`
+
+	// Background color or gradient of the item.
+	Background string `json:"background,omitempty"`
+
+	// Whether the item is displayed.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Opacity of the item, from 0 to 1.
+	Opacity *float64 `json:"opacity,omitempty"`
+}
+
+// ItemType implements FeedbackItem.
+func (*PixmapItem) ItemType() ItemType { return ItemPixmap }
+
+// BarItem updates a bar item of a layout.
+type BarItem struct {
+	// The value of the bar, within Range.
+	Value float64 `json:"value"`
+
+	// Range of the bar value. Defaults to 0-100.
+	Range *Range `json:"range,omitempty"`
+
+	// Subtype of the bar: 0 rectangle, 1 double rectangle, 2 trapezoid, 3 double trapezoid, 4 groove.
+	SubType int `json:"subtype,omitempty"`
+
+	// Color of the bar background, or gradient of a gradient bar.
+	BarBackground string `json:"bar_bg_c,omitempty"`
+
+	// Color of the bar border.
+	BarBorderColor string `json:"bar_border_c,omitempty"`
+
+	// Color of the bar filled part.
+	BarFillColor string `json:"bar_fill_c,omitempty"`
+
+	// Width of the bar border, in pixels.
+	BorderWidth int `json:"border_w,omitempty"`
+
+	// Background color or gradient of the item.
+	Background string `json:"background,omitempty"`
+
+	// Whether the item is displayed.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Opacity of the item, from 0 to 1.
+	Opacity *float64 `json:"opacity,omitempty"`
+}
+
+// ItemType implements FeedbackItem.
+func (*BarItem) ItemType() ItemType { return ItemBar }
+
+// GBarItem updates a gradient bar item of a layout.
+type GBarItem struct {
+	BarItem
+
+	// Height of the bar, in pixels.
+	BarHeight int `json:"bar_h,omitempty"`
+}
+
+// ItemType implements FeedbackItem.
+func (*GBarItem) ItemType() ItemType { return ItemGBar }
+
+// FeedbackPayload is a typed setFeedback payload for a layout.
+type FeedbackPayload interface {
+	// Layout returns the layout the payload applies to.
+	Layout() string
+
+	// Payload returns the setFeedback payload.
+	Payload() (SendEventSetFeedbackPayload, error)
+}
+
+// items builds a payload from the non-nil items.
+func items(items map[string]FeedbackItem) SendEventSetFeedbackPayload {
+	payload := make(SendEventSetFeedbackPayload, len(items))
+	for key, item := range items {
+		if item != nil && !isNilItem(item) {
+			payload[key] = item
+		}
+	}
+
+	return payload
+}
+
+// isNilItem reports whether item is a typed nil pointer.
+func isNilItem(item FeedbackItem) bool {
+	switch v := item.(type) {
+	case *TextItem:
+		return v == nil
+	case *PixmapItem:
+		return v == nil
+	case *BarItem:
+		return v == nil
+	case *GBarItem:
+		return v == nil
+	}
+
+	return false
+}
+
+// IconFeedback updates the items of the IconLayout.
+type IconFeedback struct {
+	Title *TextItem
+	Icon  *PixmapItem
+}
+
+// Layout implements FeedbackPayload.
+func (*IconFeedback) Layout() string { return IconLayout }
+
+// Payload implements FeedbackPayload.
+func (f *IconFeedback) Payload() (SendEventSetFeedbackPayload, error) {
+	return items(map[string]FeedbackItem{"title": f.Title, "icon": f.Icon}), nil
+}
+
+// CanvasFeedback updates the items of the CanvasLayout.
+type CanvasFeedback struct {
+	Title      *TextItem
+	FullCanvas *PixmapItem
+	Canvas     *PixmapItem
+}
+
+// Layout implements FeedbackPayload.
+func (*CanvasFeedback) Layout() string { return CanvasLayout }
+
+// Payload implements FeedbackPayload.
+func (f *CanvasFeedback) Payload() (SendEventSetFeedbackPayload, error) {
+	return items(map[string]FeedbackItem{"title": f.Title, "full-canvas": f.FullCanvas, "canvas": f.Canvas}), nil
+}
+
+// ValueFeedback updates the items of the ValueLayout.
+type ValueFeedback struct {
+	Title *TextItem
+	Icon  *PixmapItem
+	Value *TextItem
+}
+
+// Layout implements FeedbackPayload.
+func (*ValueFeedback) Layout() string { return ValueLayout }
+
+// Payload implements FeedbackPayload.
+func (f *ValueFeedback) Payload() (SendEventSetFeedbackPayload, error) {
+	return items(map[string]FeedbackItem{"title": f.Title, "icon": f.Icon, "value": f.Value}), nil
+}
+
+// IndicatorFeedback updates the items of the IndicatorLayout.
+type IndicatorFeedback struct {
+	Title     *TextItem
+	Icon      *PixmapItem
+	Value     *TextItem
+	Indicator *BarItem
+}
+
+// Layout implements FeedbackPayload.
+func (*IndicatorFeedback) Layout() string { return IndicatorLayout }
+
+// Payload implements FeedbackPayload.
+func (f *IndicatorFeedback) Payload() (SendEventSetFeedbackPayload, error) {
+	return items(map[string]FeedbackItem{"title": f.Title, "icon": f.Icon, "value": f.Value, "indicator": f.Indicator}), nil
+}
+
+// GradientIndicatorFeedback updates the items of the GradientIndicatorLayout.
+type GradientIndicatorFeedback struct {
+	Title     *TextItem
+	Icon      *PixmapItem
+	Value     *TextItem
+	Indicator *GBarItem
+}
+
+// Layout implements FeedbackPayload.
+func (*GradientIndicatorFeedback) Layout() string { return GradientIndicatorLayout }
+
+// Payload implements FeedbackPayload.
+func (f *GradientIndicatorFeedback) Payload() (SendEventSetFeedbackPayload, error) {
+	return items(map[string]FeedbackItem{"title": f.Title, "icon": f.Icon, "value": f.Value, "indicator": f.Indicator}), nil
+}
+
+// DoubleIndicatorFeedback updates the items of the DoubleIndicatorLayout.
+type DoubleIndicatorFeedback struct {
+	Title      *TextItem
+	Icon1      *PixmapItem
+	Icon2      *PixmapItem
+	Indicator1 *BarItem
+	Indicator2 *BarItem
+}
+
+// Layout implements FeedbackPayload.
+func (*DoubleIndicatorFeedback) Layout() string { return DoubleIndicatorLayout }
+
+// Payload implements FeedbackPayload.
+func (f *DoubleIndicatorFeedback) Payload() (SendEventSetFeedbackPayload, error) {
+	return items(map[string]FeedbackItem{
+		"title":      f.Title,
+		"icon1":      f.Icon1,
+		"icon2":      f.Icon2,
+		"indicator1": f.Indicator1,
+		"indicator2": f.Indicator2,
+	}), nil
+}
+
+// Feedback builds a setFeedback payload for any layout by item key,
+// refusing items which do not exist in the layout.
+type Feedback struct {
+	layout  string
+	items   map[string]ItemType
+	payload SendEventSetFeedbackPayload
+	err     error
+}
+
+// NewFeedback returns a Feedback for given built-in layout.
+func NewFeedback(layout string) *Feedback {
+	f := &Feedback{
+		layout:  layout,
+		items:   builtinLayouts[layout],
+		payload: make(SendEventSetFeedbackPayload),
+	}
+
+	if f.items == nil {
+		f.err = fmt.Errorf("%w: unknown layout %q", ErrInvalidFeedback, layout)
+	}
+
+	return f
+}
+
+// Set the value of the item with given key.
+func (f *Feedback) Set(key string, item FeedbackItem) *Feedback {
+	if f.err != nil {
+		return f
+	}
+
+	t, ok := f.items[key]
+	switch {
+	case item == nil || isNilItem(item):
+		f.err = fmt.Errorf("%w: item %q has no value", ErrInvalidFeedback, key)
+	case !ok:
+		f.err = fmt.Errorf("%w: layout %q has no item %q, available items: %v", ErrInvalidFeedback, f.layout, key, f.keys())
+	case t != item.ItemType():
+		f.err = fmt.Errorf("%w: item %q of layout %q is a %s, not a %s", ErrInvalidFeedback, key, f.layout, t, item.ItemType())
+	default:
+		f.payload[key] = item
+	}
+
+	return f
+}
+
+// keys returns the sorted item keys of the layout.
+func (f *Feedback) keys() []string {
+	keys := make([]string, 0, len(f.items))
+	for key := range f.items {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Layout implements FeedbackPayload.
+func (f *Feedback) Layout() string { return f.layout }
+
+// Payload implements FeedbackPayload. It returns the first error met while setting items.
+func (f *Feedback) Payload() (SendEventSetFeedbackPayload, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return f.payload, nil
+}
+
+// SendFeedback change the feedback of an action with given context using a typed payload.
+// It fails if the action is known to display another layout.
+func (s *StreamDeck) SendFeedback(context string, feedback FeedbackPayload) error {
+	payload, err := feedback.Payload()
+	if err != nil {
+		return fmt.Errorf("layout %q: %w", feedback.Layout(), err)
+	}
+
+	if current, ok := s.layouts.current(context); ok && current != feedback.Layout() {
+		return fmt.Errorf("%w: feedback for layout %q sent to context %s displaying layout %q", ErrInvalidFeedback, feedback.Layout(), context, current)
+	}
+
+	s.SetFeedback(context, &payload)
+	return nil
+}
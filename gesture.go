@@ -0,0 +1,333 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLongPress is the duration a key must be held to trigger a LongPress.
+	defaultLongPress = 500 * time.Millisecond
+
+	// defaultRepeatInterval is the interval between Repeat gestures.
+	defaultRepeatInterval = 100 * time.Millisecond
+)
+
+// GestureType is the kind of gesture recognized on a key.
+type GestureType uint8
+
+const (
+	// Press is a short press, emitted when the key is released.
+	Press GestureType = iota
+
+	// LongPress is emitted once while the key is held longer than GestureOptions.LongPress.
+	LongPress
+
+	// DoublePress is emitted instead of two Press when the key is pressed twice within GestureOptions.DoublePress.
+	DoublePress
+
+	// Repeat is emitted repeatedly while the key is held, after GestureOptions.RepeatDelay.
+	Repeat
+)
+
+// String implements fmt.Stringer.
+func (t GestureType) String() string {
+	switch t {
+	case Press:
+		return "press"
+	case LongPress:
+		return "longPress"
+	case DoublePress:
+		return "doublePress"
+	case Repeat:
+		return "repeat"
+	}
+
+	return "unknown"
+}
+
+// Gesture is a gesture recognized on the key of an action instance.
+type Gesture struct {
+	// Type of the gesture.
+	Type GestureType
+
+	// An opaque value identifying the instance's action.
+	Context string
+
+	// Duration the key has been held, for LongPress and Repeat.
+	Duration time.Duration
+
+	// Count is the number of Repeat emitted since the key is held, starting at 1.
+	Count int
+
+	// Event is the key event which started the gesture.
+	Event *ReceivedEvent
+}
+
+// GestureHandlerFunc receives recognized gestures.
+type GestureHandlerFunc func(gesture *Gesture) error
+
+// GestureOptions configures a GestureRecognizer.
+type GestureOptions struct {
+	// Action restricts recognition to the action with this UUID. Empty means all actions.
+	Action string
+
+	// LongPress is the duration a key must be held to trigger a LongPress. Defaults to 500ms.
+	LongPress time.Duration
+
+	// DoublePress is the delay within which a second press is a DoublePress.
+	// Press is delayed by this duration. Zero disables DoublePress.
+	DoublePress time.Duration
+
+	// RepeatDelay is the duration a key must be held before Repeat is emitted. Zero disables Repeat.
+	RepeatDelay time.Duration
+
+	// RepeatInterval is the interval between Repeat gestures. Defaults to 100ms.
+	RepeatInterval time.Duration
+}
+
+// keyState is the state of the key of an action instance.
+type keyState struct {
+	generation int // incremented on each keyDown
+	held       bool
+	down       time.Time
+	event      *ReceivedEvent
+	consumed   bool // a LongPress or Repeat has been emitted for this press
+	long       *time.Timer
+	repeat     *time.Timer
+	pending    *time.Timer // first press waiting for a second one
+}
+
+// stop cancels running timers of the current press.
+func (k *keyState) stop() {
+	if k.long != nil {
+		k.long.Stop()
+	}
+
+	if k.repeat != nil {
+		k.repeat.Stop()
+	}
+}
+
+// GestureRecognizer turns keyDown and keyUp events into gestures, per action instance.
+type GestureRecognizer struct {
+	s          *StreamDeck
+	unregister func()
+	opts       GestureOptions
+	h          GestureHandlerFunc
+
+	mu   sync.Mutex
+	keys map[string]*keyState
+}
+
+// NewGestureRecognizer creates a GestureRecognizer sending gestures to h.
+// It watches key events in the order they are received, whatever the dispatcher.
+func (s *StreamDeck) NewGestureRecognizer(opts GestureOptions, h GestureHandlerFunc) *GestureRecognizer {
+	if opts.LongPress <= 0 {
+		opts.LongPress = defaultLongPress
+	}
+
+	if opts.RepeatInterval <= 0 {
+		opts.RepeatInterval = defaultRepeatInterval
+	}
+
+	g := &GestureRecognizer{
+		s:    s,
+		opts: opts,
+		h:    h,
+		keys: make(map[string]*keyState),
+	}
+
+	g.unregister = s.addInterceptor(g.intercept)
+	return g
+}
+
+// Close unregisters the GestureRecognizer, it stops watching key events.
+// Pending gestures are dropped.
+func (g *GestureRecognizer) Close() {
+	g.unregister()
+
+	g.mu.Lock()
+	contexts := make([]string, 0, len(g.keys))
+	for context := range g.keys {
+		contexts = append(contexts, context)
+	}
+	g.mu.Unlock()
+
+	for _, context := range contexts {
+		g.forget(context)
+	}
+}
+
+// intercept implements interceptor. It never suppresses events.
+func (g *GestureRecognizer) intercept(event *ReceivedEvent) (bool, []*ReceivedEvent) {
+	if g.opts.Action != "" && event.Action != g.opts.Action {
+		return false, nil
+	}
+
+	switch event.Event {
+	case KeyDown:
+		g.keyDown(event)
+	case KeyUp:
+		g.keyUp(event)
+	case WillDisappear:
+		g.forget(event.Context)
+	}
+
+	return false, nil
+}
+
+// key returns the state of the key with given context. g.mu must be held.
+func (g *GestureRecognizer) key(context string) *keyState {
+	k, ok := g.keys[context]
+	if !ok {
+		k = new(keyState)
+		g.keys[context] = k
+	}
+
+	return k
+}
+
+// keyDown starts a press, scheduling LongPress and Repeat.
+func (g *GestureRecognizer) keyDown(event *ReceivedEvent) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	k := g.key(event.Context)
+	k.stop()
+	k.generation++
+	k.held = true
+	k.down = time.Now()
+	k.event = event
+	k.consumed = false
+
+	generation := k.generation
+	k.long = time.AfterFunc(g.opts.LongPress, func() {
+		g.longPress(event.Context, generation)
+	})
+
+	if g.opts.RepeatDelay > 0 {
+		k.repeat = time.AfterFunc(g.opts.RepeatDelay, func() {
+			g.repeat(event.Context, generation, 1)
+		})
+	}
+}
+
+// keyUp ends a press, emitting Press or DoublePress unless the press was consumed.
+// It is called from the process loop, so gestures are emitted from their own goroutine.
+func (g *GestureRecognizer) keyUp(event *ReceivedEvent) {
+	g.mu.Lock()
+
+	k := g.key(event.Context)
+
+	// Multi actions only send keyUp
+	if !k.held || (event.Payload != nil && event.Payload.IsInMultiAction) {
+		g.mu.Unlock()
+		go g.emit(&Gesture{Type: Press, Context: event.Context, Event: event})
+		return
+	}
+
+	k.stop()
+	k.held = false
+	start := k.event
+
+	if k.consumed {
+		g.mu.Unlock()
+		return
+	}
+
+	if g.opts.DoublePress <= 0 {
+		g.mu.Unlock()
+		go g.emit(&Gesture{Type: Press, Context: event.Context, Event: start})
+		return
+	}
+
+	if k.pending != nil {
+		k.pending.Stop()
+		k.pending = nil
+		g.mu.Unlock()
+		go g.emit(&Gesture{Type: DoublePress, Context: event.Context, Event: start})
+		return
+	}
+
+	var pending *time.Timer
+	pending = time.AfterFunc(g.opts.DoublePress, func() {
+		g.mu.Lock()
+		if k.pending != pending {
+			g.mu.Unlock()
+			return
+		}
+
+		k.pending = nil
+		g.mu.Unlock()
+		g.emit(&Gesture{Type: Press, Context: event.Context, Event: start})
+	})
+	k.pending = pending
+	g.mu.Unlock()
+}
+
+// longPress emits LongPress if the press of given generation is still held.
+func (g *GestureRecognizer) longPress(context string, generation int) {
+	g.mu.Lock()
+	k, ok := g.keys[context]
+	if !ok || !k.held || k.generation != generation {
+		g.mu.Unlock()
+		return
+	}
+
+	k.consumed = true
+	gesture := &Gesture{Type: LongPress, Context: context, Duration: time.Since(k.down), Event: k.event}
+	g.mu.Unlock()
+
+	g.emit(gesture)
+}
+
+// repeat emits Repeat while the press of given generation is held, and schedules the next one.
+func (g *GestureRecognizer) repeat(context string, generation int, count int) {
+	g.mu.Lock()
+	k, ok := g.keys[context]
+	if !ok || !k.held || k.generation != generation {
+		g.mu.Unlock()
+		return
+	}
+
+	k.consumed = true
+	gesture := &Gesture{Type: Repeat, Context: context, Duration: time.Since(k.down), Count: count, Event: k.event}
+	k.repeat = time.AfterFunc(g.opts.RepeatInterval, func() {
+		g.repeat(context, generation, count+1)
+	})
+	g.mu.Unlock()
+
+	g.emit(gesture)
+}
+
+// forget drops the state of the key with given context.
+func (g *GestureRecognizer) forget(context string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if k, ok := g.keys[context]; ok {
+		k.stop()
+		if k.pending != nil {
+			k.pending.Stop()
+		}
+
+		delete(g.keys, context)
+	}
+}
+
+// emit sends a gesture to the handler, retrying it when the error handler asks to.
+func (g *GestureRecognizer) emit(gesture *Gesture) {
+	for attempt := 1; ; attempt++ {
+		err := g.h(gesture)
+		if err == nil || errors.Is(err, ErrStopPropagation) {
+			return
+		}
+
+		if !g.s.reportError(gesture.Event, fmt.Errorf("gesture [%s]: %w", gesture.Type, err), attempt) {
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package sdk
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// defaultGIFDelay is used for GIF frames without delay, as browsers do.
+const defaultGIFDelay = 100 * time.Millisecond
+
+// PlayGIF plays the animated GIF read from r on the key of the action with given context.
+// Frames are resized for the device displaying the action and encoded once before playing.
+// Any animation already running on this context is stopped.
+func (s *StreamDeck) PlayGIF(context string, r io.Reader, loop bool) (*Animation, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode gif: %w", err)
+	}
+
+	frames, err := gifFrames(g, s.KeyImageSize(context))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Animate(context, frames, WithLoop(loop)), nil
+}
+
+// gifFrames composes the GIF frames following their disposal methods, then resizes and encodes them.
+func gifFrames(g *gif.GIF, size int) ([]Frame, error) {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() && len(g.Image) > 0 {
+		bounds = g.Image[0].Bounds()
+	}
+
+	canvas := image.NewRGBA(bounds)
+	frames := make([]Frame, 0, len(g.Image))
+	for i, p := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, p.Bounds(), p, p.Bounds().Min, draw.Over)
+
+		encoded, err := EncodeImage(fitImage(canvas, size))
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode gif frame %d: %w", i, err)
+		}
+
+		delay := defaultGIFDelay
+		if i < len(g.Delay) && g.Delay[i] > 1 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+
+		frames = append(frames, Frame{Image: encoded, Delay: delay})
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, p.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return frames, nil
+}
@@ -1,5 +1,10 @@
 package sdk
 
+import (
+	"context"
+	"time"
+)
+
 // HandlerFunc receive event from StreamDeck SDK.
 type HandlerFunc func(event *ReceivedEvent) error
 
@@ -8,14 +13,67 @@ type Handler interface {
 	Handle(event *ReceivedEvent) error
 }
 
+// ContextHandlerFunc receive event from StreamDeck SDK with a context
+// cancelled on plugin shutdown and when the action instance of the event disappears.
+type ContextHandlerFunc func(ctx context.Context, event *ReceivedEvent) error
+
+// ContextHandler same as ContextHandlerFunc but with interface.
+type ContextHandler interface {
+	HandleContext(ctx context.Context, event *ReceivedEvent) error
+}
+
 // Handler register given handlers.
 func (s *StreamDeck) Handler(h ...Handler) {
 	for _, handler := range h {
-		s.handlers = append(s.handlers, handler.Handle)
+		s.On(contextless(handler.Handle))
 	}
 }
 
 // HandlerFunc register given HandlerFunc.
 func (s *StreamDeck) HandlerFunc(h ...HandlerFunc) {
-	s.handlers = append(s.handlers, h...)
+	for _, handler := range h {
+		s.On(contextless(handler))
+	}
+}
+
+// ContextHandler register given context-aware handlers.
+func (s *StreamDeck) ContextHandler(h ...ContextHandler) {
+	for _, handler := range h {
+		s.On(handler.HandleContext)
+	}
+}
+
+// ContextHandlerFunc register given ContextHandlerFunc.
+func (s *StreamDeck) ContextHandlerFunc(h ...ContextHandlerFunc) {
+	for _, handler := range h {
+		s.On(handler)
+	}
+}
+
+// contextless adapts a HandlerFunc to a ContextHandlerFunc.
+func contextless(h HandlerFunc) ContextHandlerFunc {
+	return func(_ context.Context, event *ReceivedEvent) error {
+		return h(event)
+	}
+}
+
+// Timeout bounds the duration of each call of given handler: its context is cancelled after d.
+func Timeout(d time.Duration, h ContextHandlerFunc) ContextHandlerFunc {
+	return func(ctx context.Context, event *ReceivedEvent) error {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+
+		return h(ctx, event)
+	}
+}
+
+// call runs a handler, bounded by the handler timeout if any.
+func (s *StreamDeck) call(ctx context.Context, h ContextHandlerFunc, event *ReceivedEvent) error {
+	if s.handlerTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.handlerTimeout)
+		defer cancel()
+	}
+
+	return h(ctx, event)
 }
@@ -0,0 +1,121 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/draw"
+	"image/png"
+)
+
+// defaultKeyImageSize is the key image size used when the device is unknown.
+const defaultKeyImageSize = 72
+
+// keyImageSizes is the size in pixels of a key image for each device type.
+var keyImageSizes = map[Device]int{
+	KESDSDKDeviceTypeStreamDeck:       72,
+	KESDSDKDeviceTypeStreamDeckMini:   80,
+	KESDSDKDeviceTypeStreamDeckXL:     96,
+	KESDSDKDeviceTypeStreamDeckMobile: 72,
+	KESDSDKDeviceTypeCorsairGKeys:     72,
+	KESDSDKDeviceTypeStreamDeckPlus:   120,
+}
+
+// KeyImageSize returns the size in pixels of the key image of the action with given context.
+func (s *StreamDeck) KeyImageSize(context string) int {
+	if d, ok := s.instances.deviceType(context); ok {
+		if size, ok := keyImageSizes[d]; ok {
+			return size
+		}
+	}
+
+	return defaultKeyImageSize
+}
+
+// EncodeImage encodes given image as a base64 PNG data URI, ready to be used with SetImage.
+func EncodeImage(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// fitImage scales src to fit in a size x size square, keeping its aspect ratio and centering it.
+func fitImage(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	w, h := size, size
+	if b.Dx() > b.Dy() {
+		h = b.Dy() * size / b.Dx()
+	} else if b.Dy() > b.Dx() {
+		w = b.Dx() * size / b.Dy()
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	offset := image.Pt((size-w)/2, (size-h)/2)
+	scaled := resizeImage(src, w, h)
+	draw.Draw(dst, scaled.Bounds().Add(offset), scaled, image.Point{}, draw.Src)
+	return dst
+}
+
+// resizeImage scales src to w x h using bilinear interpolation.
+func resizeImage(src image.Image, w, h int) *image.RGBA {
+	rgba, ok := src.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(src.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
+	}
+
+	b := rgba.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if w <= 0 || h <= 0 || b.Empty() {
+		return dst
+	}
+
+	sx := float64(b.Dx()) / float64(w)
+	sy := float64(b.Dy()) / float64(h)
+	for y := 0; y < h; y++ {
+		fy := (float64(y)+0.5)*sy - 0.5
+		y0, wy := split(fy, b.Dy())
+		for x := 0; x < w; x++ {
+			fx := (float64(x)+0.5)*sx - 0.5
+			x0, wx := split(fx, b.Dx())
+
+			x1, y1 := x0+1, y0+1
+			if x1 >= b.Dx() {
+				x1 = x0
+			}
+			if y1 >= b.Dy() {
+				y1 = y0
+			}
+
+			p00 := rgba.PixOffset(b.Min.X+x0, b.Min.Y+y0)
+			p10 := rgba.PixOffset(b.Min.X+x1, b.Min.Y+y0)
+			p01 := rgba.PixOffset(b.Min.X+x0, b.Min.Y+y1)
+			p11 := rgba.PixOffset(b.Min.X+x1, b.Min.Y+y1)
+			d := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				top := float64(rgba.Pix[p00+c])*(1-wx) + float64(rgba.Pix[p10+c])*wx
+				bottom := float64(rgba.Pix[p01+c])*(1-wx) + float64(rgba.Pix[p11+c])*wx
+				dst.Pix[d+c] = uint8(top*(1-wy) + bottom*wy + 0.5)
+			}
+		}
+	}
+
+	return dst
+}
+
+// split returns the integer part of f clamped to [0, n) and its fractional weight.
+func split(f float64, n int) (int, float64) {
+	if f < 0 {
+		return 0, 0
+	}
+
+	i := int(f)
+	if i >= n-1 {
+		return n - 1, 0
+	}
+
+	return i, f - float64(i)
+}
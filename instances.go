@@ -0,0 +1,82 @@
+package sdk
+
+import "sync"
+
+// instance describes an action instance currently displayed on a device.
+type instance struct {
+	action     string
+	device     string
+	controller Controller
+}
+
+// instances keeps track of visible action instances and connected devices.
+type instances struct {
+	mu       sync.RWMutex
+	contexts map[string]instance
+	devices  map[string]Device
+	settings map[string]map[string]interface{} // last known settings by context
+}
+
+func newInstances(info *Info) *instances {
+	i := &instances{
+		contexts: make(map[string]instance),
+		devices:  make(map[string]Device),
+		settings: make(map[string]map[string]interface{}),
+	}
+
+	for _, d := range info.Devices {
+		i.devices[d.ID] = Device(d.Type)
+	}
+
+	return i
+}
+
+// observe updates known instances and devices from incoming events.
+func (i *instances) observe(event *ReceivedEvent) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if event.Context != "" && event.Payload != nil && event.Payload.Settings != nil {
+		i.settings[event.Context] = event.Payload.Settings
+	}
+
+	switch event.Event {
+	case WillAppear:
+		inst := instance{action: event.Action, device: event.Device}
+		if event.Payload != nil {
+			inst.controller = event.Payload.Controller
+		}
+
+		i.contexts[event.Context] = inst
+	case WillDisappear:
+		delete(i.contexts, event.Context)
+		delete(i.settings, event.Context)
+	case DeviceDidConnect:
+		i.devices[event.Device] = event.DeviceInfo.Type
+	case DeviceDidDisconnect:
+		delete(i.devices, event.Device)
+	}
+}
+
+// get returns the instance with given context.
+func (i *instances) get(context string) (instance, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	inst, ok := i.contexts[context]
+	return inst, ok
+}
+
+// deviceType returns the type of the device displaying the instance with given context.
+func (i *instances) deviceType(context string) (Device, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	inst, ok := i.contexts[context]
+	if !ok {
+		return 0, false
+	}
+
+	d, ok := i.devices[inst.device]
+	return d, ok
+}
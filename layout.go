@@ -0,0 +1,357 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidLayout is returned when a custom layout is invalid.
+var ErrInvalidLayout = errors.New("invalid layout")
+
+const (
+	// LayoutWidth is the width in pixels of the touch display area of an encoder.
+	LayoutWidth = 200
+
+	// LayoutHeight is the height in pixels of the touch display area of an encoder.
+	LayoutHeight = 100
+
+	// maxZOrder is the highest z-order of a layout item.
+	maxZOrder = 700
+)
+
+// CustomLayout describes a custom Stream Deck + touch display layout.
+// See [Layouts](https://docs.elgato.com/sdk/plugins/layouts-sd+)
+type CustomLayout struct {
+	// Unique identifier of the layout.
+	ID string `json:"id"`
+
+	// Items displayed by the layout.
+	Items []LayoutItem `json:"items"`
+}
+
+// LayoutItem is a single item of a CustomLayout.
+type LayoutItem struct {
+	// Key identifying the item in setFeedback payloads.
+	Key string `json:"key"`
+
+	// Type of the item.
+	Type ItemType `json:"type"`
+
+	// Rect is the position and size of the item: x, y, width and height.
+	Rect [4]int `json:"rect"`
+
+	// ZOrder layers items, from 0 to 700. Items with the same z-order must not overlap.
+	ZOrder int `json:"zOrder,omitempty"`
+
+	// Default value: a text, an image path or a bar value.
+	Value interface{} `json:"value,omitempty"`
+
+	// Whether the item is displayed.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Opacity of the item, from 0 to 1.
+	Opacity *float64 `json:"opacity,omitempty"`
+
+	// Background color or gradient of the item.
+	Background string `json:"background,omitempty"`
+
+	// Font of a text item.
+	Font *Font `json:"font,omitempty"`
+
+	// Alignment of a text item: left, center or right.
+	Alignment string `json:"alignment,omitempty"`
+
+	// Color of a text item.
+	Color string `json:"color,omitempty"`
+
+	// How overflowing text of a text item is handled: clip, ellipsis or fade.
+	TextOverflow string `json:"text-overflow,omitempty"`
+
+	// Range of a bar or gradient bar item.
+	Range *Range `json:"range,omitempty"`
+
+	// Subtype of a bar or gradient bar item.
+	SubType int `json:"subtype,omitempty"`
+
+	// Color of the bar background, or gradient of a gradient bar.
+	BarBackground string `json:"bar_bg_c,omitempty"`
+
+	// Color of the bar border.
+	BarBorderColor string `json:"bar_border_c,omitempty"`
+
+	// Color of the bar filled part.
+	BarFillColor string `json:"bar_fill_c,omitempty"`
+
+	// Width of the bar border, in pixels.
+	BorderWidth int `json:"border_w,omitempty"`
+
+	// Height of a gradient bar, in pixels.
+	BarHeight int `json:"bar_h,omitempty"`
+}
+
+// bounds returns the rectangle covered by the item.
+func (i *LayoutItem) bounds() image.Rectangle {
+	return image.Rect(i.Rect[0], i.Rect[1], i.Rect[0]+i.Rect[2], i.Rect[1]+i.Rect[3])
+}
+
+// problems returns what is wrong with the item on its own.
+func (i *LayoutItem) problems() []string {
+	var problems []string
+	add := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf("item %q: ", i.Key)+fmt.Sprintf(format, a...))
+	}
+
+	switch i.Type {
+	case ItemText, ItemPixmap, ItemBar, ItemGBar:
+	default:
+		add("unknown type %q", i.Type)
+	}
+
+	x, y, w, h := i.Rect[0], i.Rect[1], i.Rect[2], i.Rect[3]
+	if w <= 0 || h <= 0 {
+		add("empty rect %v", i.Rect)
+	} else if x < 0 || y < 0 || x+w > LayoutWidth || y+h > LayoutHeight {
+		add("rect %v is out of the %dx%d canvas", i.Rect, LayoutWidth, LayoutHeight)
+	}
+
+	if i.ZOrder < 0 || i.ZOrder > maxZOrder {
+		add("zOrder %d is not between 0 and %d", i.ZOrder, maxZOrder)
+	}
+
+	if i.Opacity != nil && (*i.Opacity < 0 || *i.Opacity > 1) {
+		add("opacity %v is not between 0 and 1", *i.Opacity)
+	}
+
+	if i.Type != ItemText && (i.Font != nil || i.Alignment != "" || i.Color != "" || i.TextOverflow != "") {
+		add("font, alignment, color and text-overflow only apply to text items")
+	}
+
+	switch i.Alignment {
+	case "", "left", "center", "right":
+	default:
+		add("unknown alignment %q", i.Alignment)
+	}
+
+	switch i.TextOverflow {
+	case "", "clip", "ellipsis", "fade":
+	default:
+		add("unknown text-overflow %q", i.TextOverflow)
+	}
+
+	isBar := i.Type == ItemBar || i.Type == ItemGBar
+	if !isBar && (i.Range != nil || i.SubType != 0 || i.BarBackground != "" || i.BarBorderColor != "" || i.BarFillColor != "" || i.BorderWidth != 0) {
+		add("range, subtype and bar colors only apply to bar items")
+	}
+
+	if i.Type != ItemGBar && i.BarHeight != 0 {
+		add("bar_h only applies to gbar items")
+	}
+
+	if i.Range != nil && i.Range.Min >= i.Range.Max {
+		add("range min %v is not lower than max %v", i.Range.Min, i.Range.Max)
+	}
+
+	return problems
+}
+
+// Validate checks the layout items fit in the canvas, have unique keys and do not overlap.
+func (l *CustomLayout) Validate() error {
+	var problems []string
+	if l.ID == "" {
+		problems = append(problems, "missing id")
+	}
+
+	if len(l.Items) == 0 {
+		problems = append(problems, "no items")
+	}
+
+	keys := make(map[string]bool, len(l.Items))
+	for idx := range l.Items {
+		item := &l.Items[idx]
+		if item.Key == "" {
+			problems = append(problems, fmt.Sprintf("item #%d: missing key", idx))
+		} else if keys[item.Key] {
+			problems = append(problems, fmt.Sprintf("item %q: duplicated key", item.Key))
+		}
+
+		keys[item.Key] = true
+		problems = append(problems, item.problems()...)
+
+		for _, other := range l.Items[:idx] {
+			if other.ZOrder == item.ZOrder && other.bounds().Overlaps(item.bounds()) {
+				problems = append(problems, fmt.Sprintf("item %q overlaps item %q with the same zOrder %d", item.Key, other.Key, item.ZOrder))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w %q: %s", ErrInvalidLayout, l.ID, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// itemTypes returns the type of each item by key.
+func (l *CustomLayout) itemTypes() map[string]ItemType {
+	types := make(map[string]ItemType, len(l.Items))
+	for _, item := range l.Items {
+		types[item.Key] = item.Type
+	}
+
+	return types
+}
+
+// Feedback returns a Feedback for this layout, referenced by given path in SetFeedbackLayout.
+func (l *CustomLayout) Feedback(path string) *Feedback {
+	return &Feedback{
+		layout:  path,
+		items:   l.itemTypes(),
+		payload: make(SendEventSetFeedbackPayload),
+	}
+}
+
+// WriteFile validates the layout and writes it as JSON to given path.
+func (l *CustomLayout) WriteFile(path string) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// LoadLayout reads and validates the custom layout at given path.
+func LoadLayout(path string) (*CustomLayout, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var l CustomLayout
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, fmt.Errorf("cannot decode layout %s: %w", path, err)
+	}
+
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+// layouts keeps track of custom layouts and of the layout displayed by each context.
+type layouts struct {
+	mu       sync.RWMutex
+	custom   map[string]map[string]ItemType // item types by layout path
+	contexts map[string]string              // layout by context
+}
+
+func newLayouts() *layouts {
+	return &layouts{
+		custom:   make(map[string]map[string]ItemType),
+		contexts: make(map[string]string),
+	}
+}
+
+// items returns the item types of given layout.
+func (l *layouts) items(layout string) (map[string]ItemType, bool) {
+	if items, ok := builtinLayouts[layout]; ok {
+		return items, true
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	items, ok := l.custom[layout]
+	return items, ok
+}
+
+// current returns the layout last set for given context.
+func (l *layouts) current(context string) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	layout, ok := l.contexts[context]
+	return layout, ok
+}
+
+// set records the layout displayed by given context.
+func (l *layouts) set(context string, layout string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.contexts[context] = layout
+}
+
+// observe forgets layouts of instances reset by the Stream Deck application.
+func (l *layouts) observe(event *ReceivedEvent) {
+	if event.Event != WillAppear && event.Event != WillDisappear {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.contexts, event.Context)
+}
+
+// unknownKeys returns the keys of payload which are not items of the layout displayed by given context.
+func (l *layouts) unknownKeys(context string, payload SendEventSetFeedbackPayload) (string, []string) {
+	layout, ok := l.current(context)
+	if !ok {
+		return "", nil
+	}
+
+	items, ok := l.items(layout)
+	if !ok {
+		return layout, nil
+	}
+
+	var unknown []string
+	for key := range payload {
+		if _, ok := items[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+
+	sort.Strings(unknown)
+	return layout, unknown
+}
+
+// RegisterLayout validates a custom layout and registers it with the path used in SetFeedbackLayout,
+// so that feedback sent to contexts displaying it can be checked.
+func (s *StreamDeck) RegisterLayout(path string, layout *CustomLayout) error {
+	if err := layout.Validate(); err != nil {
+		return err
+	}
+
+	s.layouts.mu.Lock()
+	defer s.layouts.mu.Unlock()
+
+	s.layouts.custom[path] = layout.itemTypes()
+	return nil
+}
+
+// Feedback returns a Feedback for given built-in or registered custom layout.
+func (s *StreamDeck) Feedback(layout string) *Feedback {
+	items, ok := s.layouts.items(layout)
+	if !ok {
+		return NewFeedback(layout)
+	}
+
+	return &Feedback{
+		layout:  layout,
+		items:   items,
+		payload: make(SendEventSetFeedbackPayload),
+	}
+}
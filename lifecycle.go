@@ -0,0 +1,71 @@
+package sdk
+
+import (
+	"context"
+	"sync"
+)
+
+// lifecycles holds a context per action instance, cancelled when the instance disappears
+// and derived from the plugin context, cancelled on shutdown.
+type lifecycles struct {
+	mu        sync.Mutex
+	base      context.Context
+	instances map[string]instanceLifecycle
+}
+
+// instanceLifecycle is the context of an action instance.
+type instanceLifecycle struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newLifecycles() *lifecycles {
+	return &lifecycles{
+		base:      context.Background(),
+		instances: make(map[string]instanceLifecycle),
+	}
+}
+
+// start sets the plugin context.
+func (l *lifecycles) start(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.base = ctx
+}
+
+// context returns the context to handle given event with.
+// Events without a visible action instance, and willDisappear events, get the plugin context.
+func (l *lifecycles) context(event *ReceivedEvent) context.Context {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lc, ok := l.instances[event.Context]; ok && event.Event != WillDisappear {
+		return lc.ctx
+	}
+
+	return l.base
+}
+
+// observe creates the context of appearing instances and cancels the one of disappearing instances.
+func (l *lifecycles) observe(event *ReceivedEvent) {
+	if event.Context == "" {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch event.Event {
+	case WillAppear:
+		if _, ok := l.instances[event.Context]; !ok {
+			ctx, cancel := context.WithCancel(l.base)
+			l.instances[event.Context] = instanceLifecycle{ctx: ctx, cancel: cancel}
+		}
+	case WillDisappear:
+		if lc, ok := l.instances[event.Context]; ok {
+			lc.cancel()
+			delete(l.instances, event.Context)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MachineState is a state of a StateMachine, with its visuals and hooks.
+type MachineState struct {
+	// Name identifying the state, persisted in settings.
+	Name string
+
+	// KeyState is the state of the action displayed in this state, set with SetState. Nil keeps the current one.
+	KeyState *uint8
+
+	// Image displayed in this state, set with SetImage. Empty keeps the current one.
+	Image string
+
+	// Title displayed in this state, set with SetTitle. Empty keeps the current one.
+	Title string
+
+	// Timeout triggers a transition to TimeoutTo once the state has been active for this duration. Zero disables it.
+	Timeout time.Duration
+
+	// TimeoutTo is the state entered on timeout.
+	TimeoutTo string
+
+	// OnEnter is called when entering the state.
+	// Hooks run while transitions are serialized: they must not call Transition synchronously.
+	OnEnter func(ctx context.Context, m *StateMachine) error
+
+	// OnExit is called when leaving the state.
+	OnExit func(ctx context.Context, m *StateMachine) error
+}
+
+// Transition moves a StateMachine to another state when an event is received.
+type Transition struct {
+	// From is the state the transition applies to. Empty means any state.
+	From string
+
+	// On is the event triggering the transition, such as KeyDown or KeyUp.
+	On EventName
+
+	// To is the entered state.
+	To string
+
+	// Guard, when set, must return true for the transition to happen.
+	Guard func(event *ReceivedEvent) bool
+}
+
+// StateMachineDefinition describes the states and transitions of a StateMachine.
+type StateMachineDefinition struct {
+	// Initial is the state entered when no state is persisted.
+	Initial string
+
+	// States of the machine.
+	States []MachineState
+
+	// Transitions between states, the first matching one wins.
+	Transitions []Transition
+
+	// SettingsKey is the action setting the current state is persisted to and restored from.
+	// Empty disables persistence.
+	SettingsKey string
+}
+
+// validate checks states and transitions reference existing states.
+func (d *StateMachineDefinition) validate() (map[string]*MachineState, error) {
+	states := make(map[string]*MachineState, len(d.States))
+	for i := range d.States {
+		state := &d.States[i]
+		if state.Name == "" {
+			return nil, fmt.Errorf("state #%d has no name", i)
+		}
+
+		if _, ok := states[state.Name]; ok {
+			return nil, fmt.Errorf("duplicated state %q", state.Name)
+		}
+
+		states[state.Name] = state
+	}
+
+	if _, ok := states[d.Initial]; !ok {
+		return nil, fmt.Errorf("unknown initial state %q", d.Initial)
+	}
+
+	for _, state := range d.States {
+		if _, ok := states[state.TimeoutTo]; state.Timeout > 0 && !ok {
+			return nil, fmt.Errorf("state %q times out to unknown state %q", state.Name, state.TimeoutTo)
+		}
+	}
+
+	for i, t := range d.Transitions {
+		if _, ok := states[t.From]; t.From != "" && !ok {
+			return nil, fmt.Errorf("transition #%d from unknown state %q", i, t.From)
+		}
+
+		if _, ok := states[t.To]; !ok {
+			return nil, fmt.Errorf("transition #%d to unknown state %q", i, t.To)
+		}
+	}
+
+	return states, nil
+}
+
+// StateMachine drives an action instance through states, triggered by its events or timers,
+// displaying each state visuals and persisting the current state in its settings.
+type StateMachine struct {
+	s          *StreamDeck
+	unregister func()
+	context    string
+	def        StateMachineDefinition
+	states     map[string]*MachineState
+
+	// transitions serializes state changes, from exit hooks to enter hooks.
+	transitions sync.Mutex
+
+	mu         sync.Mutex
+	current    string
+	timer      *time.Timer
+	generation int // incremented on each state change, to ignore stale timers
+}
+
+// NewStateMachine creates a StateMachine for the action with given context and registers it as a handler.
+// The current state is restored from the settings of the action when available, otherwise the initial state is entered.
+func (s *StreamDeck) NewStateMachine(context string, def StateMachineDefinition) (*StateMachine, error) {
+	states, err := def.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid state machine: %w", err)
+	}
+
+	m := &StateMachine{
+		s:       s,
+		context: context,
+		def:     def,
+		states:  states,
+	}
+
+	if !m.restore(s.Settings(context)) {
+		if err := m.Transition(def.Initial); err != nil {
+			return nil, err
+		}
+	}
+
+	m.unregister = s.On(m.HandleContext)
+	return m, nil
+}
+
+// Current returns the name of the current state.
+func (m *StateMachine) Current() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.current
+}
+
+// Close unregisters the StateMachine handler and stops its timer.
+func (m *StateMachine) Close() {
+	m.unregister()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.generation++
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+}
+
+// Transition moves the machine to given state, calling exit and enter hooks.
+func (m *StateMachine) Transition(to string) error {
+	m.transitions.Lock()
+	defer m.transitions.Unlock()
+
+	return m.transition(m.s.lifecycles.context(&ReceivedEvent{Context: m.context}), to)
+}
+
+// HandleContext implements ContextHandler.
+func (m *StateMachine) HandleContext(ctx context.Context, event *ReceivedEvent) error {
+	if event.Context != m.context {
+		return nil
+	}
+
+	m.transitions.Lock()
+	defer m.transitions.Unlock()
+
+	switch event.Event {
+	case WillAppear:
+		if event.Payload != nil && m.restore(event.Payload.Settings) {
+			return nil
+		}
+
+		m.enter(m.Current())
+		return nil
+	case WillDisappear:
+		m.mu.Lock()
+		m.generation++
+		if m.timer != nil {
+			m.timer.Stop()
+		}
+		m.mu.Unlock()
+		return nil
+	}
+
+	current := m.Current()
+	for _, t := range m.def.Transitions {
+		if t.On == event.Event && (t.From == "" || t.From == current) && (t.Guard == nil || t.Guard(event)) {
+			return m.transition(ctx, t.To)
+		}
+	}
+
+	return nil
+}
+
+// transition leaves the current state and enters given one. m.transitions must be held.
+func (m *StateMachine) transition(ctx context.Context, to string) error {
+	next, ok := m.states[to]
+	if !ok {
+		return fmt.Errorf("unknown state %q", to)
+	}
+
+	m.mu.Lock()
+	previous := m.states[m.current]
+	m.mu.Unlock()
+
+	if previous != nil && previous.OnExit != nil {
+		if err := previous.OnExit(ctx, m); err != nil {
+			return fmt.Errorf("exit state %q: %w", previous.Name, err)
+		}
+	}
+
+	m.enter(to)
+
+	if m.def.SettingsKey != "" {
+		m.s.MergeSettings(m.context, map[string]interface{}{m.def.SettingsKey: to})
+	}
+
+	if next.OnEnter != nil {
+		if err := next.OnEnter(ctx, m); err != nil {
+			return fmt.Errorf("enter state %q: %w", to, err)
+		}
+	}
+
+	return nil
+}
+
+// enter makes given state current, displaying its visuals and starting its timer, without calling hooks.
+// m.transitions must be held, unless the machine is not registered yet.
+func (m *StateMachine) enter(name string) {
+	state := m.states[name]
+
+	m.mu.Lock()
+	m.current = name
+	m.generation++
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+
+	if state.Timeout > 0 {
+		generation := m.generation
+		m.timer = time.AfterFunc(state.Timeout, func() {
+			m.timeout(generation, state.TimeoutTo)
+		})
+	}
+	m.mu.Unlock()
+
+	if state.KeyState != nil {
+		m.s.SetState(m.context, *state.KeyState)
+	}
+
+	if state.Image != "" {
+		m.s.SetImage(m.context, state.Image)
+	}
+
+	if state.Title != "" {
+		m.s.SetTitleWith(m.context, state.Title)
+	}
+}
+
+// timeout triggers the timed transition of the state entered at given generation, if still current.
+// Its errors go through the error handler, the transition is retried only while the state is unchanged.
+func (m *StateMachine) timeout(generation int, to string) {
+	m.transitions.Lock()
+	defer m.transitions.Unlock()
+
+	event := &ReceivedEvent{Context: m.context}
+	for attempt := 1; ; attempt++ {
+		// Checked once transitions are serialized, as another transition may have happened meanwhile.
+		m.mu.Lock()
+		stale := generation != m.generation
+		m.mu.Unlock()
+
+		if stale {
+			return
+		}
+
+		ctx := m.s.lifecycles.context(event)
+		err := m.transition(ctx, to)
+		if err == nil || !m.s.reportError(event, fmt.Errorf("state machine timeout: %w", err), attempt) {
+			return
+		}
+	}
+}
+
+// restore enters the state persisted in given settings, without calling hooks.
+// It reports whether a state was restored.
+func (m *StateMachine) restore(settings map[string]interface{}) bool {
+	if m.def.SettingsKey == "" {
+		return false
+	}
+
+	name, ok := settings[m.def.SettingsKey].(string)
+	if !ok {
+		return false
+	}
+
+	if _, ok := m.states[name]; !ok {
+		return false
+	}
+
+	m.enter(name)
+	return true
+}
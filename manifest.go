@@ -0,0 +1,575 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// manifestSDKVersion is the SDK version supported by this package.
+	manifestSDKVersion = 2
+
+	// encoderMinimumVersion is the first Stream Deck software version supporting encoders.
+	encoderMinimumVersion = "6.0"
+)
+
+var (
+	// ErrInvalidManifest is returned when a manifest is incomplete or inconsistent.
+	ErrInvalidManifest = errors.New("invalid manifest")
+
+	// ErrManifestMismatch is reported when an outgoing event does not match the manifest.
+	ErrManifestMismatch = errors.New("event does not match the manifest")
+
+	// uuidPattern matches reverse-DNS identifiers used as plugin and action UUIDs.
+	uuidPattern = regexp.MustCompile(`^[a-z0-9-]+(\.[a-z0-9-]+)+$`)
+
+	// versionPattern matches software and OS versions.
+	versionPattern = regexp.MustCompile(`^\d+(\.\d+)*$`)
+)
+
+// Manifest describes the manifest.json file of a plugin.
+// See [Manifest](https://docs.elgato.com/sdk/plugins/manifest)
+type Manifest struct {
+	// Unique identifier of the plugin, in reverse-DNS format. Defaults to the plugin folder name.
+	UUID string `json:"UUID,omitempty"`
+
+	// Name of the plugin.
+	Name string `json:"Name"`
+
+	// Version of the plugin.
+	Version string `json:"Version"`
+
+	// Author of the plugin.
+	Author string `json:"Author"`
+
+	// Description of the plugin.
+	Description string `json:"Description"`
+
+	// Path of the plugin icon, without extension.
+	Icon string `json:"Icon"`
+
+	// Category the actions are listed in, and its icon path without extension.
+	Category     string `json:"Category,omitempty"`
+	CategoryIcon string `json:"CategoryIcon,omitempty"`
+
+	// Path of the plugin executable, optionally specific to each platform.
+	CodePath    string `json:"CodePath,omitempty"`
+	CodePathMac string `json:"CodePathMac,omitempty"`
+	CodePathWin string `json:"CodePathWin,omitempty"`
+
+	// Path of the Property Inspector shared by all actions.
+	PropertyInspectorPath string `json:"PropertyInspectorPath,omitempty"`
+
+	// Size of windows opened by the Property Inspector: width and height.
+	DefaultWindowSize []int `json:"DefaultWindowSize,omitempty"`
+
+	// Website of the plugin.
+	URL string `json:"URL,omitempty"`
+
+	// Version of the SDK the plugin is written for.
+	SDKVersion int `json:"SDKVersion"`
+
+	// Operating systems supported by the plugin.
+	OS []ManifestOS `json:"OS"`
+
+	// Stream Deck software supported by the plugin.
+	Software ManifestSoftware `json:"Software"`
+
+	// Applications whose launch and termination are notified to the plugin.
+	ApplicationsToMonitor *ManifestApplications `json:"ApplicationsToMonitor,omitempty"`
+
+	// Profiles distributed with the plugin.
+	Profiles []ManifestProfile `json:"Profiles,omitempty"`
+
+	// Node.js runtime of JavaScript plugins.
+	Nodejs *ManifestNodejs `json:"Nodejs,omitempty"`
+
+	// Actions of the plugin.
+	Actions []ManifestAction `json:"Actions"`
+}
+
+// ManifestAction describes an action in the manifest.
+type ManifestAction struct {
+	// Unique identifier of the action, prefixed with the plugin UUID.
+	UUID string `json:"UUID"`
+
+	// Name of the action.
+	Name string `json:"Name"`
+
+	// Path of the action icon in the actions list, without extension.
+	Icon string `json:"Icon"`
+
+	// Tooltip of the action in the actions list.
+	Tooltip string `json:"Tooltip,omitempty"`
+
+	// States of the action, one or two.
+	States []ManifestState `json:"States"`
+
+	// Controllers the action supports: Keypad and/or Encoder. Defaults to Keypad.
+	Controllers []Controller `json:"Controllers,omitempty"`
+
+	// Encoder describes the action on the Stream Deck + touch display and dials.
+	Encoder *ManifestEncoder `json:"Encoder,omitempty"`
+
+	// Path of the action Property Inspector.
+	PropertyInspectorPath string `json:"PropertyInspectorPath,omitempty"`
+
+	// Whether the action can be used in multi actions. Defaults to true.
+	SupportedInMultiActions *bool `json:"SupportedInMultiActions,omitempty"`
+
+	// Whether the action is listed in the actions list. Defaults to true.
+	VisibleInActionsList *bool `json:"VisibleInActionsList,omitempty"`
+
+	// Whether the user can change the title. Defaults to true.
+	UserTitleEnabled *bool `json:"UserTitleEnabled,omitempty"`
+
+	// Prevents the state from changing automatically on keyUp.
+	DisableAutomaticStates bool `json:"DisableAutomaticStates,omitempty"`
+
+	// Prevents the Property Inspector from being cached.
+	DisableCaching bool `json:"DisableCaching,omitempty"`
+}
+
+// controllers returns the controllers of the action, defaulting to the keypad.
+func (a *ManifestAction) controllers() []Controller {
+	if len(a.Controllers) == 0 {
+		return []Controller{KeyPad}
+	}
+
+	return a.Controllers
+}
+
+// Supports reports whether the action supports given controller.
+func (a *ManifestAction) Supports(c Controller) bool {
+	for _, controller := range a.controllers() {
+		if strings.EqualFold(string(controller), string(c)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ManifestState describes a state of an action.
+type ManifestState struct {
+	// Path of the state image, without extension.
+	Image string `json:"Image"`
+
+	// Path of the state image displayed in multi actions, without extension.
+	MultiActionImage string `json:"MultiActionImage,omitempty"`
+
+	// Name of the state, displayed in multi actions.
+	Name string `json:"Name,omitempty"`
+
+	// Default title and its style.
+	Title          string      `json:"Title,omitempty"`
+	ShowTitle      *bool       `json:"ShowTitle,omitempty"`
+	TitleColor     string      `json:"TitleColor,omitempty"`
+	TitleAlignment string      `json:"TitleAlignment,omitempty"`
+	FontFamily     string      `json:"FontFamily,omitempty"`
+	FontStyle      string      `json:"FontStyle,omitempty"`
+	FontSize       json.Number `json:"FontSize,omitempty"`
+	FontUnderline  *bool       `json:"FontUnderline,omitempty"`
+}
+
+// ManifestEncoder describes an encoder action.
+type ManifestEncoder struct {
+	// Layout of the touch display: a built-in layout or the path of a custom layout.
+	Layout string `json:"layout,omitempty"`
+
+	// Path of the icon displayed in the touch display, without extension.
+	Icon string `json:"Icon,omitempty"`
+
+	// Path of the touch display background, without extension.
+	Background string `json:"background,omitempty"`
+
+	// Color of the action when stacked.
+	StackColor string `json:"StackColor,omitempty"`
+
+	// Default descriptions of the encoder interactions.
+	TriggerDescription *ManifestTriggerDescription `json:"TriggerDescription,omitempty"`
+}
+
+// ManifestTriggerDescription describes the encoder interactions of an action.
+type ManifestTriggerDescription struct {
+	// Description of the long-touch interaction with the touch display.
+	LongTouch string `json:"LongTouch,omitempty"`
+
+	// Description of the push interaction with the dial.
+	Push string `json:"Push,omitempty"`
+
+	// Description of the rotate interaction with the dial.
+	Rotate string `json:"Rotate,omitempty"`
+
+	// Description of the touch interaction with the touch display.
+	Touch string `json:"Touch,omitempty"`
+}
+
+// ManifestOS describes a supported operating system.
+type ManifestOS struct {
+	// Platform: mac or windows.
+	Platform string `json:"Platform"`
+
+	// Minimum version of the operating system.
+	MinimumVersion string `json:"MinimumVersion"`
+}
+
+// ManifestSoftware describes the supported Stream Deck software.
+type ManifestSoftware struct {
+	// Minimum version of the Stream Deck software.
+	MinimumVersion string `json:"MinimumVersion"`
+}
+
+// ManifestApplications lists monitored applications by platform.
+type ManifestApplications struct {
+	Mac     []string `json:"mac,omitempty"`
+	Windows []string `json:"windows,omitempty"`
+}
+
+// ManifestProfile describes a profile distributed with the plugin.
+type ManifestProfile struct {
+	// Path of the profile file, without extension.
+	Name string `json:"Name"`
+
+	// Type of device the profile is for.
+	DeviceType Device `json:"DeviceType"`
+
+	// Whether the profile is read-only.
+	ReadOnly bool `json:"ReadOnly,omitempty"`
+
+	// Prevents switching to the profile when installed.
+	DontAutoSwitchWhenInstalled bool `json:"DontAutoSwitchWhenInstalled,omitempty"`
+
+	// Whether the profile is installed automatically. Defaults to true.
+	AutoInstall *bool `json:"AutoInstall,omitempty"`
+}
+
+// ManifestNodejs describes the Node.js runtime of JavaScript plugins.
+type ManifestNodejs struct {
+	Version string `json:"Version"`
+	Debug   string `json:"Debug,omitempty"`
+}
+
+// LoadManifest reads the manifest at given path.
+func LoadManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("cannot decode manifest %s: %w", path, err)
+	}
+
+	return &m, nil
+}
+
+// WriteFile writes the manifest as JSON to given path.
+func (m *Manifest) WriteFile(path string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Action returns the action with given UUID.
+func (m *Manifest) Action(uuid string) (*ManifestAction, bool) {
+	for i := range m.Actions {
+		if m.Actions[i].UUID == uuid {
+			return &m.Actions[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Validate checks the manifest is complete and consistent.
+// When dir is the plugin folder, referenced icons and layouts must exist in it,
+// and the plugin UUID defaults to the folder name.
+func (m *Manifest) Validate(dir string) error {
+	v := &manifestValidator{dir: dir}
+	v.validate(m)
+	if len(v.problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidManifest, strings.Join(v.problems, "; "))
+	}
+
+	return nil
+}
+
+// manifestValidator collects the problems of a manifest.
+type manifestValidator struct {
+	dir      string
+	problems []string
+}
+
+func (v *manifestValidator) add(format string, a ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, a...))
+}
+
+// require adds a problem if value is empty.
+func (v *manifestValidator) require(field string, value string) {
+	if value == "" {
+		v.add("missing %s", field)
+	}
+}
+
+// icon checks an image path without extension exists in the plugin folder.
+func (v *manifestValidator) icon(field string, path string) {
+	if path == "" || v.dir == "" {
+		return
+	}
+
+	candidates := []string{path, path + ".png", path + "@2x.png", path + ".svg"}
+	for _, c := range candidates {
+		if _, err := os.Stat(filepath.Join(v.dir, c)); err == nil {
+			return
+		}
+	}
+
+	v.add("%s: image %q not found", field, path)
+}
+
+// file checks a path exists in the plugin folder.
+func (v *manifestValidator) file(field string, path string) {
+	if path == "" || v.dir == "" {
+		return
+	}
+
+	if _, err := os.Stat(filepath.Join(v.dir, path)); err != nil {
+		v.add("%s: file %q not found", field, path)
+	}
+}
+
+func (v *manifestValidator) validate(m *Manifest) {
+	v.require("Name", m.Name)
+	v.require("Version", m.Version)
+	v.require("Author", m.Author)
+	v.require("Description", m.Description)
+	v.require("Icon", m.Icon)
+	v.icon("Icon", m.Icon)
+	v.icon("CategoryIcon", m.CategoryIcon)
+	v.file("PropertyInspectorPath", m.PropertyInspectorPath)
+
+	if m.CodePath == "" && (m.CodePathMac == "" || m.CodePathWin == "") {
+		v.add("missing CodePath, or CodePathMac and CodePathWin")
+	}
+
+	if m.SDKVersion != manifestSDKVersion {
+		v.add("SDKVersion %d is not supported, expected %d", m.SDKVersion, manifestSDKVersion)
+	}
+
+	if len(m.DefaultWindowSize) != 0 && len(m.DefaultWindowSize) != 2 {
+		v.add("DefaultWindowSize must hold a width and a height")
+	}
+
+	if !versionPattern.MatchString(m.Software.MinimumVersion) {
+		v.add("invalid Software.MinimumVersion %q", m.Software.MinimumVersion)
+	}
+
+	if len(m.OS) == 0 {
+		v.add("missing OS")
+	}
+
+	for _, system := range m.OS {
+		if system.Platform != "mac" && system.Platform != "windows" {
+			v.add("OS: unknown platform %q", system.Platform)
+		}
+
+		if !versionPattern.MatchString(system.MinimumVersion) {
+			v.add("OS %s: invalid MinimumVersion %q", system.Platform, system.MinimumVersion)
+		}
+	}
+
+	for i, p := range m.Profiles {
+		if p.Name == "" {
+			v.add("profile #%d: missing Name", i)
+		}
+
+		if p.DeviceType > KESDSDKDeviceTypeStreamDeckPlus {
+			v.add("profile %q: unknown DeviceType %d", p.Name, p.DeviceType)
+		}
+	}
+
+	uuid := m.UUID
+	if uuid == "" && v.dir != "" {
+		uuid = strings.TrimSuffix(filepath.Base(filepath.Clean(v.dir)), ".sdPlugin")
+	}
+
+	if uuid != "" && !uuidPattern.MatchString(uuid) {
+		v.add("plugin UUID %q is not in reverse-DNS format", uuid)
+	}
+
+	if len(m.Actions) == 0 {
+		v.add("missing Actions")
+	}
+
+	seen := make(map[string]bool, len(m.Actions))
+	for i := range m.Actions {
+		a := &m.Actions[i]
+		if seen[a.UUID] {
+			v.add("action %q: duplicated UUID", a.UUID)
+		}
+
+		seen[a.UUID] = true
+		v.action(a, uuid, m.Software.MinimumVersion)
+	}
+}
+
+func (v *manifestValidator) action(a *ManifestAction, plugin string, software string) {
+	name := fmt.Sprintf("action %q", a.UUID)
+	switch {
+	case a.UUID == "":
+		v.add("action %q: missing UUID", a.Name)
+	case !uuidPattern.MatchString(a.UUID):
+		v.add("%s: UUID is not in reverse-DNS format", name)
+	case plugin != "" && !strings.HasPrefix(a.UUID, plugin+"."):
+		v.add("%s: UUID is not prefixed with the plugin UUID %q", name, plugin)
+	}
+
+	v.require(name+" Name", a.Name)
+	v.require(name+" Icon", a.Icon)
+	v.icon(name+" Icon", a.Icon)
+	v.file(name+" PropertyInspectorPath", a.PropertyInspectorPath)
+
+	if len(a.States) < 1 || len(a.States) > 2 {
+		v.add("%s: has %d states, expected 1 or 2", name, len(a.States))
+	}
+
+	for i, s := range a.States {
+		field := fmt.Sprintf("%s state #%d", name, i)
+		v.require(field+" Image", s.Image)
+		v.icon(field+" Image", s.Image)
+		v.icon(field+" MultiActionImage", s.MultiActionImage)
+	}
+
+	for _, c := range a.Controllers {
+		if !strings.EqualFold(string(c), string(KeyPad)) && !strings.EqualFold(string(c), string(Encoder)) {
+			v.add("%s: unknown controller %q", name, c)
+		}
+	}
+
+	if !a.Supports(Encoder) {
+		if a.Encoder != nil {
+			v.add("%s: Encoder is set but the action does not support the Encoder controller", name)
+		}
+
+		return
+	}
+
+	if compareVersions(software, encoderMinimumVersion) < 0 {
+		v.add("%s: Encoder controller requires Software.MinimumVersion %s", name, encoderMinimumVersion)
+	}
+
+	if a.Encoder == nil {
+		return
+	}
+
+	v.icon(name+" Encoder.Icon", a.Encoder.Icon)
+	v.icon(name+" Encoder.background", a.Encoder.Background)
+
+	layout := a.Encoder.Layout
+	if _, ok := builtinLayouts[layout]; layout == "" || ok {
+		return
+	}
+
+	if v.dir == "" {
+		if !strings.HasSuffix(layout, ".json") {
+			v.add("%s: unknown layout %q", name, layout)
+		}
+
+		return
+	}
+
+	if _, err := LoadLayout(filepath.Join(v.dir, layout)); err != nil {
+		v.add("%s: layout: %v", name, err)
+	}
+}
+
+// compareVersions compares dotted versions, returning -1, 0 or 1.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// GenerateManifest returns a copy of base with given actions, validated without plugin folder.
+func GenerateManifest(base Manifest, actions ...ManifestAction) (*Manifest, error) {
+	m := base
+	m.Actions = append([]ManifestAction(nil), actions...)
+	if m.SDKVersion == 0 {
+		m.SDKVersion = manifestSDKVersion
+	}
+
+	if err := m.Validate(""); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Manifest returns the plugin manifest, if set with WithManifest or WithManifestFile.
+func (s *StreamDeck) Manifest() (*Manifest, bool) {
+	return s.manifest, s.manifest != nil
+}
+
+// checkManifest reports why given outgoing event does not match the manifest
+// description of the action instance it targets.
+func (s *StreamDeck) checkManifest(event *SendEvent) error {
+	if s.manifest == nil || event.Context == "" {
+		return nil
+	}
+
+	inst, ok := s.instances.get(event.Context)
+	if !ok {
+		return nil
+	}
+
+	action, ok := s.manifest.Action(inst.action)
+	if !ok {
+		return fmt.Errorf("%w: unknown action %q", ErrManifestMismatch, inst.action)
+	}
+
+	switch event.Event {
+	case SetState, SetTitle, SetImage:
+		payload, ok := event.Payload.(*SendEventPayload)
+		if ok && payload != nil && payload.State != nil && int(*payload.State) >= len(action.States) {
+			return fmt.Errorf("%w: state %d out of range, action %q has %d states", ErrManifestMismatch, *payload.State, action.UUID, len(action.States))
+		}
+	case SetFeedback, SetFeedbackLayout, SetTriggerDescription:
+		if !action.Supports(Encoder) {
+			return fmt.Errorf("%w: action %q does not support the Encoder controller", ErrManifestMismatch, action.UUID)
+		}
+
+		if inst.controller != "" && !strings.EqualFold(string(inst.controller), string(Encoder)) {
+			return fmt.Errorf("%w: context is displayed on a %s controller", ErrManifestMismatch, inst.controller)
+		}
+	}
+
+	return nil
+}
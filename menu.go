@@ -0,0 +1,253 @@
+package sdk
+
+import "sync"
+
+// dimColor is the color of the items surrounding the current one in DialMenuLayout.
+const dimColor = "#888888"
+
+// DialMenuOptions configures a DialMenu.
+type DialMenuOptions struct {
+	// Title displayed above the items.
+	Title string
+
+	// Wrap makes the menu go back to the first item when scrolling past the last one, and the other way around.
+	Wrap bool
+
+	// Layout is the path of a layout written with DialMenuLayout, used to display neighbouring items.
+	// When empty, the IndicatorLayout displays the current item and its position in the list.
+	Layout string
+
+	// OnChange is called when the highlighted item changes.
+	OnChange func(index int, item string)
+
+	// OnSelect is called when the highlighted item is selected by pushing the dial or tapping the touch display.
+	OnSelect func(index int, item string)
+
+	// OnCancel is called when the selection is cancelled with a long tap on the touch display.
+	// The previously selected item is highlighted again.
+	OnCancel func()
+}
+
+// DialMenu lets the user pick an item from a list with the dial of an encoder action instance:
+// rotate to scroll, push or tap to select, long tap to cancel.
+type DialMenu struct {
+	s          *StreamDeck
+	unregister func()
+	context    string
+	opts       DialMenuOptions
+
+	mu       sync.Mutex
+	items    []string
+	index    int // highlighted item
+	selected int // last selected item
+}
+
+// DialMenuLayout returns a custom layout displaying the title, the highlighted item and its neighbours,
+// to be written in the plugin folder and used as DialMenuOptions.Layout.
+func DialMenuLayout(id string) *CustomLayout {
+	return &CustomLayout{
+		ID: id,
+		Items: []LayoutItem{
+			{Key: "title", Type: ItemText, Rect: [4]int{0, 4, LayoutWidth, 20}, Alignment: "center"},
+			{Key: "previous", Type: ItemText, Rect: [4]int{0, 30, LayoutWidth, 20}, Alignment: "center", Color: dimColor, TextOverflow: "ellipsis"},
+			{Key: "current", Type: ItemText, Rect: [4]int{0, 52, LayoutWidth, 26}, Alignment: "center", Font: &Font{Size: 20, Weight: 600}, TextOverflow: "ellipsis"},
+			{Key: "next", Type: ItemText, Rect: [4]int{0, 80, LayoutWidth, 18}, Alignment: "center", Color: dimColor, TextOverflow: "ellipsis"},
+		},
+	}
+}
+
+// NewDialMenu creates a DialMenu of given items for the action with given context and registers it as a handler.
+func (s *StreamDeck) NewDialMenu(context string, items []string, opts DialMenuOptions) *DialMenu {
+	m := &DialMenu{
+		s:       s,
+		context: context,
+		opts:    opts,
+		items:   items,
+	}
+
+	// A layout registered by the plugin is kept, it may describe more items.
+	// DialMenuLayout is always valid, RegisterLayout cannot fail.
+	if _, ok := s.layouts.items(opts.Layout); opts.Layout != "" && !ok {
+		_ = s.RegisterLayout(opts.Layout, DialMenuLayout(opts.Layout))
+	}
+
+	m.unregister = s.On(contextless(m.Handle))
+	m.show()
+	return m
+}
+
+// SetItems replaces the items of the menu, highlighting and selecting the first one.
+func (m *DialMenu) SetItems(items []string) {
+	m.mu.Lock()
+	m.items = items
+	m.index, m.selected = 0, 0
+	m.mu.Unlock()
+
+	m.render()
+}
+
+// Selected returns the index and value of the last selected item.
+// It returns false if the menu is empty.
+func (m *DialMenu) Selected() (int, string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.selected >= len(m.items) {
+		return 0, "", false
+	}
+
+	return m.selected, m.items[m.selected], true
+}
+
+// Close unregisters the DialMenu handler, it stops handling events.
+func (m *DialMenu) Close() {
+	m.unregister()
+}
+
+// Handle implements Handler.
+func (m *DialMenu) Handle(event *ReceivedEvent) error {
+	if event.Context != m.context || event.Payload == nil {
+		return nil
+	}
+
+	switch event.Event {
+	case WillAppear:
+		m.show()
+	case DialRotate:
+		m.scroll(event.Payload.Ticks)
+	case DialDown:
+		m.selectCurrent()
+	case TouchTap:
+		if event.Payload.Hold {
+			m.cancel()
+		} else {
+			m.selectCurrent()
+		}
+	}
+
+	return nil
+}
+
+// scroll moves the highlighted item by given ticks.
+func (m *DialMenu) scroll(ticks int) {
+	m.mu.Lock()
+	n := len(m.items)
+	if n == 0 {
+		m.mu.Unlock()
+		return
+	}
+
+	index := m.index + ticks
+	if m.opts.Wrap {
+		index = ((index % n) + n) % n
+	} else if index < 0 {
+		index = 0
+	} else if index >= n {
+		index = n - 1
+	}
+
+	changed := index != m.index
+	m.index = index
+	item := m.items[index]
+	m.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	m.render()
+	if m.opts.OnChange != nil {
+		m.opts.OnChange(index, item)
+	}
+}
+
+// selectCurrent selects the highlighted item.
+func (m *DialMenu) selectCurrent() {
+	m.mu.Lock()
+	if len(m.items) == 0 {
+		m.mu.Unlock()
+		return
+	}
+
+	m.selected = m.index
+	index, item := m.index, m.items[m.index]
+	m.mu.Unlock()
+
+	if m.opts.OnSelect != nil {
+		m.opts.OnSelect(index, item)
+	}
+}
+
+// cancel highlights the last selected item again.
+func (m *DialMenu) cancel() {
+	m.mu.Lock()
+	m.index = m.selected
+	m.mu.Unlock()
+
+	m.render()
+	if m.opts.OnCancel != nil {
+		m.opts.OnCancel()
+	}
+}
+
+// show sets the menu layout and displays it.
+func (m *DialMenu) show() {
+	layout := m.opts.Layout
+	if layout == "" {
+		layout = IndicatorLayout
+	}
+
+	m.s.SetFeedbackLayout(m.context, layout)
+	m.render()
+}
+
+// render displays the highlighted item on the touch display.
+func (m *DialMenu) render() {
+	m.mu.Lock()
+	items, index := m.items, m.index
+	m.mu.Unlock()
+
+	var previous, current, next string
+	if index < len(items) {
+		current = items[index]
+	}
+
+	if n := len(items); n > 1 {
+		if index > 0 || m.opts.Wrap {
+			previous = items[(index-1+n)%n]
+		}
+
+		if index < n-1 || m.opts.Wrap {
+			next = items[(index+1)%n]
+		}
+	}
+
+	var feedback FeedbackPayload
+	if m.opts.Layout != "" {
+		feedback = m.s.Feedback(m.opts.Layout).
+			Set("title", &TextItem{Value: m.opts.Title}).
+			Set("previous", &TextItem{Value: previous, Clear: true}).
+			Set("current", &TextItem{Value: current, Clear: true}).
+			Set("next", &TextItem{Value: next, Clear: true})
+	} else {
+		indicator := &BarItem{Value: float64(index), Range: &Range{Min: 0, Max: float64(len(items) - 1)}}
+		if len(items) < 2 {
+			indicator.Range.Max = 1
+		}
+
+		f := &IndicatorFeedback{
+			Value:     &TextItem{Value: current, Clear: true},
+			Indicator: indicator,
+		}
+
+		if m.opts.Title != "" {
+			f.Title = &TextItem{Value: m.opts.Title}
+		}
+
+		feedback = f
+	}
+
+	if err := m.s.SendFeedback(m.context, feedback); err != nil {
+		m.s.Logf("[ERROR] dial menu for context [%s]: %v", m.context, err)
+	}
+}
@@ -1,5 +1,7 @@
 package sdk
 
+import "time"
+
 // Option describes a single option.
 type Option func(*StreamDeck)
 
@@ -9,3 +11,108 @@ func WithDebug(debug bool) Option {
 		deck.debug = debug
 	}
 }
+
+// WithFrameBudget sets the maximum number of frames per second sent for all key animations.
+// Frames over budget are dropped. Zero disables the limit.
+func WithFrameBudget(fps int) Option {
+	return func(deck *StreamDeck) {
+		deck.animator.budget = newTokenBucket(float64(fps), fps)
+	}
+}
+
+// WithCoalescing enables or disables coalescing of outgoing updates.
+// When enabled (the default), pending setTitle, setImage, setFeedback, setFeedbackLayout and setTriggerDescription
+// events for the same context are replaced by the latest one, and updates identical to what is already displayed are skipped.
+func WithCoalescing(enabled bool) Option {
+	return func(deck *StreamDeck) {
+		deck.outbox.coalesce = enabled
+	}
+}
+
+// WithSendPriority overrides the priority of outgoing events with given name.
+func WithSendPriority(event EventName, priority SendPriority) Option {
+	return func(deck *StreamDeck) {
+		deck.outbox.setPriority(event, priority)
+	}
+}
+
+// WithRateLimit limits the rate of outgoing events with given name.
+func WithRateLimit(event EventName, limit RateLimit) Option {
+	return func(deck *StreamDeck) {
+		deck.outbox.setLimit(event, limit)
+	}
+}
+
+// WithOrderedDispatch enables or disables ordered dispatch of incoming events.
+// When enabled, events of a same action instance are handled sequentially in the order they are received,
+// while events of different instances are handled concurrently.
+// When disabled (the default), events are handled concurrently regardless of their instance.
+func WithOrderedDispatch(enabled bool) Option {
+	return func(deck *StreamDeck) {
+		deck.ordered = enabled
+	}
+}
+
+// WithWorkers sets the number of goroutines handling incoming events.
+// Zero (the default) handles each event in its own goroutine, or uses one worker per CPU with ordered dispatch.
+func WithWorkers(n int) Option {
+	return func(deck *StreamDeck) {
+		deck.workers = n
+	}
+}
+
+// WithQueueLength sets the number of events waiting to be handled by workers before the OverflowPolicy applies.
+// With ordered dispatch, each worker has its own queue of this length.
+func WithQueueLength(n int) Option {
+	return func(deck *StreamDeck) {
+		deck.queueLength = n
+	}
+}
+
+// WithOverflowPolicy sets what happens to incoming events when workers fall behind. Defaults to OverflowBlock.
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(deck *StreamDeck) {
+		deck.overflow = policy
+	}
+}
+
+// WithHandlerTimeout bounds the duration of each handler call: its context is cancelled after d.
+// Zero (the default) means no timeout.
+func WithHandlerTimeout(d time.Duration) Option {
+	return func(deck *StreamDeck) {
+		deck.handlerTimeout = d
+	}
+}
+
+// WithErrorHandler sets what happens when a handler returns an error. Defaults to DefaultErrorHandler, also used when h is nil.
+func WithErrorHandler(h ErrorHandler) Option {
+	return func(deck *StreamDeck) {
+		if h == nil {
+			h = DefaultErrorHandler
+		}
+
+		deck.errorHandler = h
+	}
+}
+
+// WithManifest sets the plugin manifest, used to check outgoing events against the actions it describes.
+func WithManifest(m *Manifest) Option {
+	return func(deck *StreamDeck) {
+		deck.manifest = m
+	}
+}
+
+// WithManifestFile loads the plugin manifest from given path when the plugin is created.
+// See WithManifest.
+func WithManifestFile(path string) Option {
+	return func(deck *StreamDeck) {
+		deck.manifestPath = path
+	}
+}
+
+// WithStrictManifest drops outgoing events the manifest reports as invalid, instead of only logging a warning.
+func WithStrictManifest() Option {
+	return func(deck *StreamDeck) {
+		deck.manifestStrict = true
+	}
+}
@@ -0,0 +1,690 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SendPriority is the priority of an outgoing event.
+// Events with a higher priority are sent before pending events with a lower one.
+type SendPriority uint8
+
+const (
+	// PriorityControl is used for events changing the action behaviour, such as setSettings, showOk, showAlert or setState.
+	PriorityControl SendPriority = iota
+
+	// PriorityNormal is used for display updates, such as setTitle or setFeedback.
+	PriorityNormal
+
+	// PriorityBulk is used for heavy or numerous events, such as setImage or logMessage.
+	PriorityBulk
+
+	priorities = int(PriorityBulk) + 1
+)
+
+// defaultPriorities is the priority of each event. Events not listed use PriorityNormal.
+var defaultPriorities = map[EventName]SendPriority{
+	SetSettings:             PriorityControl,
+	GetSettings:             PriorityControl,
+	SetGlobalSettings:       PriorityControl,
+	GetGlobalSettings:       PriorityControl,
+	ShowOk:                  PriorityControl,
+	ShowAlert:               PriorityControl,
+	SetState:                PriorityControl,
+	OpenURL:                 PriorityControl,
+	SwitchToProfile:         PriorityControl,
+	SendToPropertyInspector: PriorityControl,
+	SetImage:                PriorityBulk,
+	LogMessage:              PriorityBulk,
+}
+
+// RateLimit limits how many events of a type are sent.
+type RateLimit struct {
+	// Rate is the number of events per second. Zero means unlimited.
+	Rate float64
+
+	// Burst is the number of events that can be sent at once.
+	Burst int
+
+	// MaxPending is the maximum number of events of this type waiting to be sent.
+	// The oldest ones are dropped when exceeded. Zero means unlimited.
+	MaxPending int
+}
+
+// OutboxStats describes outgoing events activity.
+type OutboxStats struct {
+	// Pending is the number of events waiting to be sent.
+	Pending int
+
+	// Events holds statistics by event name.
+	Events map[EventName]OutboxEventStats
+}
+
+// OutboxEventStats describes outgoing activity of a single event type.
+type OutboxEventStats struct {
+	// Sent is the number of events written to the WebSocket.
+	Sent uint64
+
+	// Coalesced is the number of events replaced by a newer one before being sent.
+	Coalesced uint64
+
+	// Deduplicated is the number of events skipped because identical to the last one delivered.
+	Deduplicated uint64
+
+	// Dropped is the number of events dropped because of RateLimit.MaxPending.
+	Dropped uint64
+
+	// TotalDelay is the total time sent events waited in the outbox.
+	TotalDelay time.Duration
+
+	// MaxDelay is the longest time a sent event waited in the outbox.
+	MaxDelay time.Duration
+}
+
+// coalescable lists events for which only the latest value sent to a context matters.
+// setSettings replaces all the settings of the action, so only the latest one matters too.
+var coalescable = map[EventName]bool{
+	SetSettings:           true,
+	SetTitle:              true,
+	SetImage:              true,
+	SetFeedback:           true,
+	SetFeedbackLayout:     true,
+	SetTriggerDescription: true,
+}
+
+// outgoing is an event waiting to be sent.
+type outgoing struct {
+	// key identifies coalescable events, empty otherwise.
+	key    string
+	event  *SendEvent
+	queued time.Time
+
+	// message is the encoded event, set when checked for duplicates.
+	message []byte
+
+	// scope is the part of the display updated by setTitle and setImage events.
+	scope updateScope
+}
+
+// sentMessage is the last message delivered for a key.
+type sentMessage struct {
+	scope   updateScope
+	message []byte
+}
+
+// outbox queues events to send to the Stream Deck application.
+// Events are sent by priority, then in order, unless their type is rate limited.
+// When coalescing is enabled, pending coalescable events for the same context are replaced by the latest one,
+// and events identical to the last one delivered are skipped.
+// setFeedback payloads are partial updates: they are merged item by item, and skipped when all their items are displayed.
+type outbox struct {
+	mu       sync.Mutex
+	queues   [priorities][]*outgoing
+	pending  map[string]*outgoing
+	sent     map[string]map[string]sentMessage // last delivered message by context then key
+	feedback map[string]map[string][]byte      // last delivered setFeedback items by context then item key
+	notify   chan struct{}
+	closed   bool
+	coalesce bool
+
+	priorities map[EventName]SendPriority
+	limits     map[EventName]RateLimit
+	buckets    map[EventName]*tokenBucket
+	counts     map[EventName]int // pending events by name
+	stats      map[EventName]*OutboxEventStats
+}
+
+func newOutbox() *outbox {
+	o := &outbox{
+		pending:    make(map[string]*outgoing),
+		sent:       make(map[string]map[string]sentMessage),
+		feedback:   make(map[string]map[string][]byte),
+		notify:     make(chan struct{}, 1),
+		coalesce:   true,
+		priorities: make(map[EventName]SendPriority, len(defaultPriorities)),
+		limits:     make(map[EventName]RateLimit),
+		buckets:    make(map[EventName]*tokenBucket),
+		counts:     make(map[EventName]int),
+		stats:      make(map[EventName]*OutboxEventStats),
+	}
+
+	for name, p := range defaultPriorities {
+		o.priorities[name] = p
+	}
+
+	return o
+}
+
+// setLimit sets the rate limit of given event.
+func (o *outbox) setLimit(name EventName, limit RateLimit) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.limits[name] = limit
+	o.buckets[name] = newTokenBucket(limit.Rate, limit.Burst)
+}
+
+// setPriority sets the priority of given event.
+func (o *outbox) setPriority(name EventName, p SendPriority) {
+	if p > PriorityBulk {
+		p = PriorityBulk
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.priorities[name] = p
+}
+
+// priority returns the priority of given event. o.mu must be held.
+func (o *outbox) priority(name EventName) SendPriority {
+	if p, ok := o.priorities[name]; ok {
+		return p
+	}
+
+	return PriorityNormal
+}
+
+// stat returns the statistics of given event. o.mu must be held.
+func (o *outbox) stat(name EventName) *OutboxEventStats {
+	st, ok := o.stats[name]
+	if !ok {
+		st = new(OutboxEventStats)
+		o.stats[name] = st
+	}
+
+	return st
+}
+
+// updateScope is the part of the display of a context an update applies to.
+type updateScope struct {
+	// base identifies the context and event, empty for events without scope.
+	base   string
+	state  int // -1 for all states
+	target int // -1 for all targets
+}
+
+// eventScope returns the scope of given setTitle or setImage event.
+func eventScope(event *SendEvent) updateScope {
+	p, ok := event.Payload.(*SendEventPayload)
+	if !ok || p == nil {
+		return updateScope{}
+	}
+
+	scope := updateScope{base: event.Context + "/" + string(event.Event), state: -1, target: -1}
+	if p.State != nil {
+		scope.state = int(*p.State)
+	}
+
+	if p.Target != nil && *p.Target != HardwareAndSoftware {
+		scope.target = int(*p.Target)
+	}
+
+	return scope
+}
+
+// covers reports whether updates of scope s replace everything updated in scope other.
+func (s updateScope) covers(other updateScope) bool {
+	return s.base != "" && s.base == other.base &&
+		(s.state < 0 || s.state == other.state) &&
+		(s.target < 0 || s.target == other.target)
+}
+
+// overlaps reports whether updates of scope s and other change a common part of the display.
+func (s updateScope) overlaps(other updateScope) bool {
+	return s.base != "" && s.base == other.base &&
+		(s.state < 0 || other.state < 0 || s.state == other.state) &&
+		(s.target < 0 || other.target < 0 || s.target == other.target)
+}
+
+// outboxKey returns the key identifying given event for coalescing, or an empty string.
+func outboxKey(event *SendEvent) string {
+	if event.Context == "" || !coalescable[event.Event] {
+		return ""
+	}
+
+	key := event.Context + "/" + string(event.Event)
+	// Updates of a single state or target do not replace each other.
+	scope := eventScope(event)
+	if scope.base != "" && scope.state >= 0 {
+		key += "/state:" + strconv.Itoa(scope.state)
+	}
+
+	if scope.base != "" && scope.target >= 0 {
+		key += "/target:" + strconv.Itoa(scope.target)
+	}
+
+	return key
+}
+
+// push queues given event. Events pushed once the outbox is closed are dropped.
+func (o *outbox) push(event *SendEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return
+	}
+
+	item := &outgoing{event: event, queued: time.Now()}
+	if o.coalesce {
+		item.key = outboxKey(event)
+	}
+
+	p := o.priority(event.Event)
+	at := -1
+	if item.key != "" {
+		item.scope = eventScope(event)
+		at = o.supersede(p, item)
+		o.pending[item.key] = item
+	}
+
+	if event.Event == SetFeedbackLayout {
+		// Feedback queued before a layout change applies to the previous layout: later feedback must not merge into it.
+		delete(o.pending, event.Context+"/"+string(SetFeedback))
+	}
+
+	queue := o.queues[p]
+	if at < 0 {
+		queue = append(queue, item)
+	} else {
+		queue = append(queue, nil)
+		copy(queue[at+1:], queue[at:])
+		queue[at] = item
+	}
+
+	o.queues[p] = queue
+	o.counts[event.Event]++
+
+	if limit := o.limits[event.Event].MaxPending; limit > 0 && o.counts[event.Event] > limit {
+		o.dropOldest(p, event.Event)
+	}
+
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+}
+
+// supersede removes the pending events of queue p replaced by item: the pending event with the same key,
+// whose setFeedback items are merged into item, and updates of a scope item covers.
+// It returns where to queue item to keep the place of the event with the same key,
+// or -1 to queue it last because it must be sent after an overlapping update,
+// or after feedback for the layout it replaces. o.mu must be held.
+func (o *outbox) supersede(p SendPriority, item *outgoing) int {
+	pending := o.pending[item.key]
+	queue := o.queues[p]
+	kept := queue[:0]
+	at := -1
+	for _, other := range queue {
+		switch {
+		case other == pending:
+			item.event = mergeFeedback(other.event, item.event)
+			item.queued = other.queued
+			at = len(kept)
+		case item.scope.covers(other.scope):
+		default:
+			if at >= 0 && (item.scope.overlaps(other.scope) || appliesTo(other, item)) {
+				at = -1
+			}
+
+			kept = append(kept, other)
+			continue
+		}
+
+		if o.pending[other.key] == other {
+			delete(o.pending, other.key)
+		}
+
+		o.counts[other.event.Event]--
+		o.stat(other.event.Event).Coalesced++
+	}
+
+	for i := len(kept); i < len(queue); i++ {
+		queue[i] = nil
+	}
+
+	o.queues[p] = kept
+	return at
+}
+
+// appliesTo reports whether the pending feedback must be displayed before the layout change,
+// as it updates the items of the layout displayed before it.
+func appliesTo(feedback, layout *outgoing) bool {
+	return layout.event.Event == SetFeedbackLayout && feedback.event.Event == SetFeedback &&
+		feedback.event.Context == layout.event.Context
+}
+
+// dropOldest removes the oldest pending event with given name. o.mu must be held.
+func (o *outbox) dropOldest(p SendPriority, name EventName) {
+	for i, item := range o.queues[p] {
+		if item.event.Event == name {
+			o.remove(p, i)
+			o.stat(name).Dropped++
+			return
+		}
+	}
+}
+
+// remove the pending event at index i of queue with priority p. o.mu must be held.
+func (o *outbox) remove(p SendPriority, i int) *outgoing {
+	queue := o.queues[p]
+	item := queue[i]
+	copy(queue[i:], queue[i+1:])
+	queue[len(queue)-1] = nil
+	o.queues[p] = queue[:len(queue)-1]
+
+	if item.key != "" && o.pending[item.key] == item {
+		delete(o.pending, item.key)
+	}
+
+	o.counts[item.event.Event]--
+	return item
+}
+
+// minWait is the shortest time to wait for a rate limited event, so the outbox never waits for nothing.
+const minWait = time.Millisecond
+
+// next returns the next event allowed to be sent, skipping duplicated ones,
+// or how long to wait before a rate limited event can be sent. o.mu must be held.
+func (o *outbox) next() (*outgoing, time.Duration) {
+	var wait time.Duration
+	limited := make(map[EventName]bool)
+	for p := range o.queues {
+		for i := 0; i < len(o.queues[p]); i++ {
+			item := o.queues[p][i]
+			name := item.event.Event
+			if limited[name] {
+				continue
+			}
+
+			bucket := o.buckets[name]
+			if !bucket.ready() {
+				limited[name] = true
+				// The bucket may have refilled since checked, wait anyway rather than not at all.
+				d := bucket.delay()
+				if d < minWait {
+					d = minWait
+				}
+
+				if wait == 0 || d < wait {
+					wait = d
+				}
+
+				continue
+			}
+
+			// Duplicates are skipped before taking a token, so they do not use the rate budget.
+			if o.duplicate(item) {
+				o.remove(SendPriority(p), i)
+				i--
+				continue
+			}
+
+			bucket.allow()
+			return o.remove(SendPriority(p), i), 0
+		}
+	}
+
+	return nil, wait
+}
+
+// pop waits for the next event to send. It returns false once ctx is done.
+func (o *outbox) pop(ctx context.Context) (*outgoing, bool) {
+	for {
+		o.mu.Lock()
+		item, wait := o.next()
+		o.mu.Unlock()
+
+		if item != nil {
+			return item, true
+		}
+
+		var timer *time.Timer
+		var retry <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			retry = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-o.notify:
+		case <-retry:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if ctx.Err() != nil {
+			return nil, false
+		}
+	}
+}
+
+// feedbackItems returns the payload of given setFeedback event.
+func feedbackItems(event *SendEvent) (SendEventSetFeedbackPayload, bool) {
+	if event.Event != SetFeedback {
+		return nil, false
+	}
+
+	switch p := event.Payload.(type) {
+	case *SendEventSetFeedbackPayload:
+		if p == nil {
+			return nil, true
+		}
+
+		return *p, true
+	case SendEventSetFeedbackPayload:
+		return p, true
+	}
+
+	return nil, false
+}
+
+// mergeFeedback returns next, with the items of pending it does not update when both are setFeedback events.
+func mergeFeedback(pending, next *SendEvent) *SendEvent {
+	old, ok := feedbackItems(pending)
+	if !ok {
+		return next
+	}
+
+	items, ok := feedbackItems(next)
+	if !ok {
+		return next
+	}
+
+	merged := make(SendEventSetFeedbackPayload, len(old)+len(items))
+	for key, value := range old {
+		merged[key] = value
+	}
+
+	for key, value := range items {
+		merged[key] = value
+	}
+
+	event := *next
+	event.Payload = &merged
+	return &event
+}
+
+// encodeItems returns the JSON encoding of each feedback item.
+func encodeItems(items SendEventSetFeedbackPayload) (map[string][]byte, bool) {
+	encoded := make(map[string][]byte, len(items))
+	for key, value := range items {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, false
+		}
+
+		encoded[key] = b
+	}
+
+	return encoded, true
+}
+
+// duplicate reports whether given item is identical to the last one delivered,
+// encoding its message on the way. setFeedback events are duplicates when each of their items is
+// identical to the last one delivered. o.mu must be held.
+func (o *outbox) duplicate(item *outgoing) bool {
+	if item.key == "" {
+		return false
+	}
+
+	message, err := json.Marshal(item.event)
+	if err != nil {
+		// The writer reports the error.
+		return false
+	}
+
+	item.message = message
+	if items, ok := feedbackItems(item.event); ok {
+		encoded, ok := encodeItems(items)
+		if !ok {
+			return false
+		}
+
+		for key, b := range encoded {
+			if !bytes.Equal(o.feedback[item.event.Context][key], b) {
+				return false
+			}
+		}
+	} else if !bytes.Equal(o.sent[item.event.Context][item.key].message, message) {
+		return false
+	}
+
+	o.stat(item.event.Event).Deduplicated++
+	return true
+}
+
+// delivered records message as the last one delivered for given item.
+func (o *outbox) delivered(item *outgoing, message []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	st := o.stat(item.event.Event)
+	st.Sent++
+	delay := time.Since(item.queued)
+	st.TotalDelay += delay
+	if delay > st.MaxDelay {
+		st.MaxDelay = delay
+	}
+
+	if item.key == "" {
+		return
+	}
+
+	context := item.event.Context
+	switch item.event.Event {
+	case SetFeedbackLayout:
+		// A new layout displays its default items.
+		delete(o.feedback, context)
+	case SetFeedback:
+		items, _ := feedbackItems(item.event)
+		encoded, ok := encodeItems(items)
+		if !ok {
+			return
+		}
+
+		displayed, ok := o.feedback[context]
+		if !ok {
+			displayed = make(map[string][]byte, len(encoded))
+			o.feedback[context] = displayed
+		}
+
+		for key, b := range encoded {
+			displayed[key] = b
+		}
+
+		return
+	}
+
+	sent, ok := o.sent[context]
+	if !ok {
+		sent = make(map[string]sentMessage)
+		o.sent[context] = sent
+	}
+
+	// Messages for overlapping scopes are no longer what is displayed.
+	for key, other := range sent {
+		if key != item.key && item.scope.overlaps(other.scope) {
+			delete(sent, key)
+		}
+	}
+
+	sent[item.key] = sentMessage{scope: item.scope, message: message}
+}
+
+// observe forgets delivered messages that may no longer be displayed.
+func (o *outbox) observe(event *ReceivedEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch event.Event {
+	case WillAppear, WillDisappear:
+		delete(o.sent, event.Context)
+		delete(o.feedback, event.Context)
+	case DidReceiveSettings:
+		// Settings may have been changed by the Property Inspector.
+		delete(o.sent[event.Context], event.Context+"/"+string(SetSettings))
+	case TitleParametersDidChange:
+		base := event.Context + "/" + string(SetTitle)
+		for key, sent := range o.sent[event.Context] {
+			if sent.scope.base == base {
+				delete(o.sent[event.Context], key)
+			}
+		}
+	}
+}
+
+// close the outbox, pending and future events are dropped.
+func (o *outbox) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.closed = true
+	o.queues = [priorities][]*outgoing{}
+	o.pending = make(map[string]*outgoing)
+	o.counts = make(map[EventName]int)
+}
+
+// snapshot returns current statistics.
+func (o *outbox) snapshot() OutboxStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	stats := OutboxStats{Events: make(map[EventName]OutboxEventStats, len(o.stats))}
+	for _, queue := range o.queues {
+		stats.Pending += len(queue)
+	}
+
+	for name, st := range o.stats {
+		stats.Events[name] = *st
+	}
+
+	return stats
+}
+
+// send queues given event to be sent to the Stream Deck application.
+func (s *StreamDeck) send(event *SendEvent) {
+	if err := s.checkManifest(event); err != nil {
+		if s.manifestStrict {
+			s.Logf("[WARN] drop event [%s] for context [%s]: %v", event.Event, event.Context, err)
+			return
+		}
+
+		s.Logf("[WARN] event [%s] for context [%s]: %v", event.Event, event.Context, err)
+	}
+
+	s.outbox.push(event)
+}
+
+// OutboxStats returns statistics about outgoing events.
+func (s *StreamDeck) OutboxStats() OutboxStats {
+	return s.outbox.snapshot()
+}
@@ -0,0 +1,214 @@
+package sdk
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// drain sends pending events the way the writer does, and returns the messages written.
+func drain(t *testing.T, o *outbox) []string {
+	t.Helper()
+
+	var messages []string
+	for {
+		o.mu.Lock()
+		item, _ := o.next()
+		o.mu.Unlock()
+
+		if item == nil {
+			return messages
+		}
+
+		message := item.message
+		if message == nil {
+			var err error
+			if message, err = json.Marshal(item.event); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		o.delivered(item, message)
+		messages = append(messages, string(message))
+	}
+}
+
+func feedbackEvent(context string, items SendEventSetFeedbackPayload) *SendEvent {
+	return &SendEvent{Event: SetFeedback, Context: context, Payload: &items}
+}
+
+func assertMessages(t *testing.T, got []string, want ...string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d messages %v, want %d %v", len(got), got, len(want), want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("message %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestOutboxMergesPendingFeedback(t *testing.T) {
+	o := newOutbox()
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"title": "T"}))
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"value": "V"}))
+
+	assertMessages(t, drain(t, o),
+		`{"event":"setFeedback","context":"ctx","payload":{"title":"T","value":"V"}}`,
+	)
+}
+
+func TestOutboxDeduplicatesFeedbackItems(t *testing.T) {
+	o := newOutbox()
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"title": "T", "value": "V"}))
+	drain(t, o)
+
+	// Items already displayed are skipped, even when sent separately.
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"title": "T"}))
+	assertMessages(t, drain(t, o))
+
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"value": "W"}))
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"title": "T"}))
+	assertMessages(t, drain(t, o),
+		`{"event":"setFeedback","context":"ctx","payload":{"title":"T","value":"W"}}`,
+	)
+
+	// A new layout displays its default items.
+	o.push(&SendEvent{Event: SetFeedbackLayout, Context: "ctx", Payload: &SendEventSetFeedbackLayoutPayload{Layout: IconLayout}})
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"title": "T"}))
+	assertMessages(t, drain(t, o),
+		`{"event":"setFeedbackLayout","context":"ctx","payload":{"layout":"$X1"}}`,
+		`{"event":"setFeedback","context":"ctx","payload":{"title":"T"}}`,
+	)
+}
+
+func TestOutboxKeepsFeedbackAfterLayoutChange(t *testing.T) {
+	o := newOutbox()
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"title": "A"}))
+	o.push(&SendEvent{Event: SetFeedbackLayout, Context: "ctx", Payload: &SendEventSetFeedbackLayoutPayload{Layout: IconLayout}})
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"title": "B"}))
+
+	assertMessages(t, drain(t, o),
+		`{"event":"setFeedback","context":"ctx","payload":{"title":"A"}}`,
+		`{"event":"setFeedbackLayout","context":"ctx","payload":{"layout":"$X1"}}`,
+		`{"event":"setFeedback","context":"ctx","payload":{"title":"B"}}`,
+	)
+}
+
+func TestOutboxDuplicatesDoNotUseRateBudget(t *testing.T) {
+	o := newOutbox()
+	o.setLimit(SetTitle, RateLimit{Rate: 1, Burst: 1})
+
+	title := func(title string) *SendEvent {
+		return &SendEvent{Event: SetTitle, Context: "ctx", Payload: &SendEventPayload{Title: title}}
+	}
+
+	o.delivered(&outgoing{key: outboxKey(title("x")), event: title("x")}, []byte(`{"event":"setTitle","context":"ctx","payload":{"title":"x"}}`))
+	o.push(title("x"))
+	assertMessages(t, drain(t, o))
+
+	o.push(title("y"))
+	assertMessages(t, drain(t, o), `{"event":"setTitle","context":"ctx","payload":{"title":"y"}}`)
+}
+
+func TestOutboxWaitsForRateLimitedEvents(t *testing.T) {
+	o := newOutbox()
+	o.setLimit(LogMessage, RateLimit{Rate: 1000, Burst: 1})
+	o.push(&SendEvent{Event: LogMessage, Payload: &SendEventPayload{Message: "a"}})
+	o.push(&SendEvent{Event: LogMessage, Payload: &SendEventPayload{Message: "b"}})
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if item, _ := o.next(); item == nil {
+		t.Fatal("first event is not rate limited")
+	}
+
+	if item, wait := o.next(); item != nil || wait < minWait {
+		t.Errorf("got item %v and wait %s, want a wait of at least %s", item, wait, minWait)
+	}
+}
+
+func titleEvent(title string, state *uint8) *SendEvent {
+	return &SendEvent{Event: SetTitle, Context: "ctx", Payload: &SendEventPayload{Title: title, State: state}}
+}
+
+func TestOutboxKeepsOverlappingUpdatesInOrder(t *testing.T) {
+	state := uint8(0)
+
+	o := newOutbox()
+	o.push(titleEvent("x", nil))
+	o.push(titleEvent("y", &state))
+	o.push(titleEvent("z", nil))
+
+	// The last update applies to all states: earlier ones are replaced.
+	assertMessages(t, drain(t, o), `{"event":"setTitle","context":"ctx","payload":{"title":"z"}}`)
+
+	o.push(titleEvent("y", &state))
+	o.push(titleEvent("x", nil))
+	o.push(titleEvent("w", &state))
+
+	// The update of state 0 must be sent after the one of all states.
+	assertMessages(t, drain(t, o),
+		`{"event":"setTitle","context":"ctx","payload":{"title":"x"}}`,
+		`{"event":"setTitle","context":"ctx","payload":{"title":"w","state":0}}`,
+	)
+}
+
+func TestOutboxInvalidatesOverlappingDeliveries(t *testing.T) {
+	state := uint8(0)
+
+	o := newOutbox()
+	o.push(titleEvent("z", nil))
+	drain(t, o)
+
+	o.push(titleEvent("y", &state))
+	drain(t, o)
+
+	// State 0 shows "y": setting all states to "z" again is not a duplicate.
+	o.push(titleEvent("z", nil))
+	assertMessages(t, drain(t, o), `{"event":"setTitle","context":"ctx","payload":{"title":"z"}}`)
+
+	// State 0 shows "z" again: its previous "y" delivery no longer applies.
+	o.push(titleEvent("y", &state))
+	assertMessages(t, drain(t, o), `{"event":"setTitle","context":"ctx","payload":{"title":"y","state":0}}`)
+}
+
+func TestOutboxKeepsFeedbackBeforeReplacedLayout(t *testing.T) {
+	layout := func(layout string) *SendEvent {
+		return &SendEvent{Event: SetFeedbackLayout, Context: "ctx", Payload: &SendEventSetFeedbackLayoutPayload{Layout: layout}}
+	}
+
+	o := newOutbox()
+	o.push(layout("A"))
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"title": "forA"}))
+	o.push(layout("B"))
+	o.push(feedbackEvent("ctx", SendEventSetFeedbackPayload{"value": "forB"}))
+
+	assertMessages(t, drain(t, o),
+		`{"event":"setFeedback","context":"ctx","payload":{"title":"forA"}}`,
+		`{"event":"setFeedbackLayout","context":"ctx","payload":{"layout":"B"}}`,
+		`{"event":"setFeedback","context":"ctx","payload":{"value":"forB"}}`,
+	)
+}
+
+func TestOutboxCoalescesSettings(t *testing.T) {
+	settings := func(value int) *SendEvent {
+		payload := map[string]interface{}{"value": value}
+		return &SendEvent{Event: SetSettings, Context: "ctx", Payload: &payload}
+	}
+
+	o := newOutbox()
+	for i := 1; i <= 10; i++ {
+		o.push(settings(i))
+	}
+
+	assertMessages(t, drain(t, o), `{"event":"setSettings","context":"ctx","payload":{"value":10}}`)
+
+	// Settings changed by the Property Inspector are sent again.
+	o.observe(&ReceivedEvent{Event: DidReceiveSettings, Context: "ctx"})
+	o.push(settings(10))
+	assertMessages(t, drain(t, o), `{"event":"setSettings","context":"ctx","payload":{"value":10}}`)
+}
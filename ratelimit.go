@@ -0,0 +1,93 @@
+package sdk
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket is a simple token bucket rate limiter.
+// A nil *tokenBucket never limits.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // maximum number of tokens
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a bucket refilled with rate tokens per second, holding up to burst tokens.
+// It returns nil when rate is not positive, meaning unlimited.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// refill adds tokens accumulated since last call. b.mu must be held.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+
+	b.last = now
+}
+
+// allow takes a token if one is available.
+func (b *tokenBucket) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// ready reports whether a token is available, without taking it.
+func (b *tokenBucket) ready() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	return b.tokens >= 1
+}
+
+// delay returns how long to wait before a token is available.
+func (b *tokenBucket) delay() time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
@@ -0,0 +1,118 @@
+package sdk
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// HandlerOption describes a single option of a registered handler.
+type HandlerOption func(*registration)
+
+// HandlerPriority sets the priority of the handler. Handlers with a higher priority run first,
+// handlers with the same priority run in registration order. Defaults to 0.
+func HandlerPriority(priority int) HandlerOption {
+	return func(r *registration) {
+		r.priority = priority
+	}
+}
+
+// HandlerOnce unregisters the handler after its first call.
+func HandlerOnce() HandlerOption {
+	return func(r *registration) {
+		r.once = true
+	}
+}
+
+// registration is a registered handler.
+type registration struct {
+	id       uint64
+	handler  ContextHandlerFunc
+	priority int
+	once     bool
+	fired    int32
+}
+
+// handlerRegistry holds registered handlers sorted by priority.
+// The slice is replaced on each change, so snapshots can be iterated without lock.
+type handlerRegistry struct {
+	mu   sync.RWMutex
+	list []*registration
+	next uint64
+}
+
+func newHandlerRegistry() *handlerRegistry {
+	return &handlerRegistry{}
+}
+
+// add registers given handler and returns its id.
+func (r *handlerRegistry) add(h ContextHandlerFunc, opts ...HandlerOption) uint64 {
+	reg := &registration{handler: h}
+	for _, opt := range opts {
+		opt(reg)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.next++
+	reg.id = r.next
+
+	list := make([]*registration, len(r.list), len(r.list)+1)
+	copy(list, r.list)
+	i := sort.Search(len(list), func(i int) bool { return list[i].priority < reg.priority })
+	list = append(list, nil)
+	copy(list[i+1:], list[i:])
+	list[i] = reg
+	r.list = list
+
+	return reg.id
+}
+
+// remove unregisters the handler with given id.
+func (r *handlerRegistry) remove(id uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, reg := range r.list {
+		if reg.id == id {
+			list := make([]*registration, 0, len(r.list)-1)
+			list = append(list, r.list[:i]...)
+			r.list = append(list, r.list[i+1:]...)
+			return
+		}
+	}
+}
+
+// snapshot returns registered handlers in call order.
+func (r *handlerRegistry) snapshot() []*registration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.list
+}
+
+// claim reports whether the handler can be called, unregistering once handlers.
+func (r *handlerRegistry) claim(reg *registration) bool {
+	if !reg.once {
+		return true
+	}
+
+	if !atomic.CompareAndSwapInt32(&reg.fired, 0, 1) {
+		return false
+	}
+
+	r.remove(reg.id)
+	return true
+}
+
+// On registers given handler and returns a function unregistering it.
+// It is safe to call while events are handled, new handlers receive the next dispatched events.
+func (s *StreamDeck) On(h ContextHandlerFunc, opts ...HandlerOption) (unregister func()) {
+	id := s.handlers.add(h, opts...)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { s.handlers.remove(id) })
+	}
+}
@@ -0,0 +1,57 @@
+package sdk
+
+// getSettings returns a copy of the last known settings of given context.
+func (i *instances) getSettings(context string) map[string]interface{} {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	settings := make(map[string]interface{}, len(i.settings[context]))
+	for k, v := range i.settings[context] {
+		settings[k] = v
+	}
+
+	return settings
+}
+
+// setSettings records the settings of given context.
+func (i *instances) setSettings(context string, settings map[string]interface{}) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.settings[context] = settings
+}
+
+// mergeSettings merges values into the last known settings of given context and returns the result.
+func (i *instances) mergeSettings(context string, values map[string]interface{}) map[string]interface{} {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	settings := make(map[string]interface{}, len(i.settings[context])+len(values))
+	for k, v := range i.settings[context] {
+		settings[k] = v
+	}
+
+	for k, v := range values {
+		settings[k] = v
+	}
+
+	i.settings[context] = settings
+	return settings
+}
+
+// Settings returns the last known settings of the action with given context,
+// as received with its events or set by the plugin.
+func (s *StreamDeck) Settings(context string) map[string]interface{} {
+	return s.instances.getSettings(context)
+}
+
+// MergeSettings change some settings of the action with given context, keeping the others.
+// SetSettings replaces all the settings of an action.
+func (s *StreamDeck) MergeSettings(context string, values map[string]interface{}) {
+	settings := s.instances.mergeSettings(context, values)
+	s.send(&SendEvent{
+		Event:   SetSettings,
+		Context: context,
+		Payload: &settings,
+	})
+}
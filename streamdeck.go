@@ -8,10 +8,16 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// interceptor is called with each incoming event before dispatch.
+// It reports whether the event must not reach handlers, and synthetic events to dispatch after it.
+type interceptor func(event *ReceivedEvent) (suppress bool, synthetic []*ReceivedEvent)
+
 // StreamDeck will handle events to send/received to/from the StreamDeck application.
 type StreamDeck struct {
 	// UUID is a unique identifier string that should be used to register the plugin once the WebSocket is opened
@@ -20,12 +26,61 @@ type StreamDeck struct {
 	// Info containing the Stream Deck application information and devices information.
 	Info *Info
 
-	conn    *websocket.Conn
-	readCh  chan *ReceivedEvent
-	writeCh chan *SendEvent
+	conn   *websocket.Conn
+	readCh chan *ReceivedEvent
+
+	// outbox queues events to send
+	outbox *outbox
 
 	// handlers will process incoming events
-	handlers []HandlerFunc
+	handlers *handlerRegistry
+
+	// actions routes events to registered actions instances
+	actions     *actions
+	actionsOnce sync.Once
+
+	// bus delivers messages published by actions
+	bus *bus
+
+	// done is closed once the plugin is shut down
+	done chan struct{}
+
+	// errorHandler decides what happens when handlers fail
+	errorHandler ErrorHandler
+
+	// handlerTimeout bounds the duration of each handler call
+	handlerTimeout time.Duration
+
+	// lifecycles holds the contexts given to handlers
+	lifecycles *lifecycles
+
+	// animator plays key animations
+	animator *animator
+
+	// instances keeps track of visible action instances
+	instances *instances
+
+	// layouts keeps track of touch display layouts
+	layouts *layouts
+
+	// dispatcher runs handlers on incoming events
+	dispatcher  dispatcher
+	ordered     bool
+	workers     int
+	queueLength int
+	overflow    OverflowPolicy
+
+	// waiters receive dispatched events for WaitFor and Subscribe
+	waiters *waiters
+
+	// interceptors may suppress incoming events or add synthetic ones
+	interceptors   []*interceptor
+	interceptorsMu sync.RWMutex
+
+	// manifest describes the plugin actions, used to check outgoing events
+	manifest       *Manifest
+	manifestPath   string
+	manifestStrict bool
 
 	debug bool
 }
@@ -83,23 +138,44 @@ func New(opts ...Option) (*StreamDeck, error) {
 	}
 
 	streamdeck := &StreamDeck{
-		UUID:     *pluginUUID,
-		Info:     &r,
-		conn:     conn,
-		readCh:   make(chan *ReceivedEvent),
-		writeCh:  make(chan *SendEvent),
-		handlers: make([]HandlerFunc, 0),
-		debug:    false,
+		UUID:         *pluginUUID,
+		Info:         &r,
+		conn:         conn,
+		readCh:       make(chan *ReceivedEvent),
+		outbox:       newOutbox(),
+		handlers:     newHandlerRegistry(),
+		lifecycles:   newLifecycles(),
+		errorHandler: DefaultErrorHandler,
+		done:         make(chan struct{}),
+		bus:          newBus(),
+		actions:      newActions(),
+		animator:     newAnimator(defaultFrameBudget),
+		instances:    newInstances(&r),
+		layouts:      newLayouts(),
+		waiters:      newWaiters(),
+		debug:        false,
 	}
 
 	for _, opt := range opts {
 		opt(streamdeck)
 	}
 
+	if streamdeck.manifestPath != "" {
+		m, err := LoadManifest(streamdeck.manifestPath)
+		if err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("cannot load manifest: %w", err)
+		}
+
+		streamdeck.manifest = m
+	}
+
 	if err := streamdeck.register(*registerEvent); err != nil {
 		return nil, fmt.Errorf("cannot register plugin: %w", err)
 	}
 
+	// Built before Start, so DispatchStats never races with it.
+	streamdeck.dispatcher = streamdeck.newDispatcher()
 	return streamdeck, nil
 }
 
@@ -109,9 +185,16 @@ func (s *StreamDeck) Start() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	defer cancel()
 
-	go s.reader(ctx) // read incoming events
-	go s.writer(ctx) // send events
-	s.process(ctx)   // will block until ctx is closed
+	s.lifecycles.start(ctx)
+
+	go s.reader(ctx)               // read incoming events
+	go s.writer(ctx)               // send events
+	go s.bus.pump(ctx, s.dispatch) // deliver published messages
+	s.process(ctx)                 // will block until ctx is closed
+
+	s.dispatcher.close()
+	s.animator.close()
+	close(s.done)
 }
 
 // reader listen on incoming messages and send them to dedicated channel.
@@ -146,24 +229,36 @@ func (s *StreamDeck) reader(ctx context.Context) {
 	}
 }
 
-// writer listen on write channel and send messages.
+// writer listen on the outbox and send messages.
 func (s *StreamDeck) writer(ctx context.Context) {
-	defer close(s.writeCh)
+	defer s.outbox.close()
 
 	if s.debug {
 		s.Log("[DEBUG] writer started")
 	}
 
 	for {
-		select {
-		case <-ctx.Done():
+		item, ok := s.outbox.pop(ctx)
+		if !ok {
 			return
-		case event := <-s.writeCh:
-			if err := s.conn.WriteJSON(event); err != nil {
-				s.Logf("[ERROR] write event [%s] for action [%s]: %v", event.Event, event.Action, err)
-				return
+		}
+
+		event := item.event
+		message := item.message
+		if message == nil {
+			var err error
+			if message, err = json.Marshal(event); err != nil {
+				s.Logf("[ERROR] encode event [%s] for action [%s]: %v", event.Event, event.Action, err)
+				continue
 			}
 		}
+
+		if err := s.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			s.Logf("[ERROR] write event [%s] for action [%s]: %v", event.Event, event.Action, err)
+			return
+		}
+
+		s.outbox.delivered(item, message)
 	}
 }
 
@@ -179,19 +274,135 @@ func (s *StreamDeck) process(ctx context.Context) {
 				return
 			}
 
-			// Send event to all to registered handlers
-			go func(event *ReceivedEvent) {
-				if s.debug {
-					s.Logf("[DEBUG] received event [%s] for action [%s]", event.Event, event.Action)
-				}
+			s.observe(e)
+			s.intercept(e)
+		}
+	}
+}
 
-				for _, h := range s.handlers {
-					if err := h(event); err != nil {
-						s.Logf("[ERROR] event [%s] action [%s]: %v", event.Event, event.Action, err)
-						s.Alert(event.Context)
-					}
-				}
-			}(e)
+// dispatch schedules given event to be sent to registered handlers,
+// with the context of its action instance at the time it is received.
+func (s *StreamDeck) dispatch(event *ReceivedEvent) {
+	s.dispatcher.dispatch(s.lifecycles.context(event), event)
+}
+
+// handle sends given event to all registered handlers,
+// then reports their errors following the error handler.
+func (s *StreamDeck) handle(ctx context.Context, event *ReceivedEvent) {
+	if s.debug {
+		s.Logf("[DEBUG] received event [%s] for action [%s]", event.Event, event.Action)
+	}
+
+	// Bus messages only reach their subscribers.
+	registry := s.handlers
+	if event.Event == BusMessage {
+		registry = s.bus.handlers
+	}
+
+	var errs []error
+	alert := false
+
+handlers:
+	for _, reg := range registry.snapshot() {
+		if !registry.claim(reg) {
+			continue
+		}
+
+		for attempt := 1; ; attempt++ {
+			err := s.call(ctx, reg.handler, event)
+			if err == nil {
+				break
+			}
+
+			if errors.Is(err, ErrStopPropagation) {
+				break handlers
+			}
+
+			action := s.errorHandler(event, err, attempt)
+			if action == ErrorRetry && attempt < maxHandlerAttempts && ctx.Err() == nil {
+				continue
+			}
+
+			switch action {
+			case ErrorIgnore:
+			case ErrorLog:
+				errs = append(errs, err)
+			case ErrorStop:
+				s.report(event, append(errs, err), alert)
+				return
+			case ErrorStopAlert:
+				s.report(event, append(errs, err), true)
+				return
+			default:
+				errs = append(errs, err)
+				alert = true
+			}
+
+			continue handlers
 		}
 	}
+
+	s.report(event, errs, alert)
+}
+
+// intercept gives interceptors a chance to suppress given event or to dispatch synthetic events,
+// then dispatches it unless suppressed. Waiters see dispatched events first.
+func (s *StreamDeck) intercept(event *ReceivedEvent) {
+	s.interceptorsMu.RLock()
+	interceptors := s.interceptors
+	s.interceptorsMu.RUnlock()
+
+	var synthetic []*ReceivedEvent
+	suppressed := false
+	for _, i := range interceptors {
+		suppress, events := (*i)(event)
+		suppressed = suppressed || suppress
+		synthetic = append(synthetic, events...)
+	}
+
+	if !suppressed {
+		s.waiters.observe(event)
+		s.dispatch(event)
+	}
+
+	for _, e := range synthetic {
+		s.waiters.observe(e)
+		s.dispatch(e)
+	}
+}
+
+// addInterceptor registers an interceptor, called in order with each incoming event before dispatch.
+// It returns a function unregistering it.
+func (s *StreamDeck) addInterceptor(i interceptor) func() {
+	s.interceptorsMu.Lock()
+	defer s.interceptorsMu.Unlock()
+
+	p := &i
+	s.interceptors = append(s.interceptors[:len(s.interceptors):len(s.interceptors)], p)
+
+	return func() {
+		s.interceptorsMu.Lock()
+		defer s.interceptorsMu.Unlock()
+
+		interceptors := make([]*interceptor, 0, len(s.interceptors))
+		for _, other := range s.interceptors {
+			if other != p {
+				interceptors = append(interceptors, other)
+			}
+		}
+
+		s.interceptors = interceptors
+	}
+}
+
+// observe lets internal components follow incoming events, in order, before they reach handlers.
+func (s *StreamDeck) observe(event *ReceivedEvent) {
+	s.instances.observe(event)
+	_, visible := s.instances.get(event.Context)
+	s.actions.observe(event, visible)
+	s.outbox.observe(event)
+	s.layouts.observe(event)
+	s.lifecycles.observe(event)
+	s.bus.observe(event)
+	s.animator.observe(event)
 }
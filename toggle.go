@@ -0,0 +1,152 @@
+package sdk
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// toggleOff is the action state displayed when a Toggle is off.
+	toggleOff uint8 = 0
+
+	// toggleOn is the action state displayed when a Toggle is on.
+	toggleOn uint8 = 1
+)
+
+// ToggleOptions configures a Toggle.
+type ToggleOptions struct {
+	// Action restricts the toggle to the two-state action with this UUID. Empty means all actions.
+	Action string
+
+	// SettingsKey is the action setting the logical state is persisted to and restored from, as a boolean.
+	// Empty disables persistence.
+	SettingsKey string
+
+	// OnOn is called when the user turns the toggle on.
+	OnOn func(ctx context.Context, event *ReceivedEvent) error
+
+	// OnOff is called when the user turns the toggle off.
+	OnOff func(ctx context.Context, event *ReceivedEvent) error
+}
+
+// Toggle implements on/off keys of two-state actions.
+// The target state is computed from the keyUp payload, honouring the user desired state within multi actions,
+// and the displayed state is reconciled with the logical one when a callback fails.
+type Toggle struct {
+	s          *StreamDeck
+	unregister func()
+	opts       ToggleOptions
+
+	mu     sync.Mutex
+	states map[string]bool // logical state by context
+}
+
+// NewToggle creates a Toggle and registers it as a handler.
+func (s *StreamDeck) NewToggle(opts ToggleOptions) *Toggle {
+	t := &Toggle{
+		s:      s,
+		opts:   opts,
+		states: make(map[string]bool),
+	}
+
+	t.unregister = s.On(t.HandleContext)
+	return t
+}
+
+// Close unregisters the Toggle handler.
+func (t *Toggle) Close() {
+	t.unregister()
+}
+
+// On reports whether the toggle of the action with given context is on.
+func (t *Toggle) On(context string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.states[context]
+}
+
+// HandleContext implements ContextHandler.
+func (t *Toggle) HandleContext(ctx context.Context, event *ReceivedEvent) error {
+	if (t.opts.Action != "" && event.Action != t.opts.Action) || event.Payload == nil {
+		return nil
+	}
+
+	switch event.Event {
+	case WillAppear:
+		t.appear(event)
+	case WillDisappear:
+		t.mu.Lock()
+		delete(t.states, event.Context)
+		t.mu.Unlock()
+	case KeyUp:
+		return t.toggle(ctx, event)
+	}
+
+	return nil
+}
+
+// appear restores the logical state of an instance and displays it.
+func (t *Toggle) appear(event *ReceivedEvent) {
+	on := event.Payload.State != toggleOff
+	if persisted, ok := event.Payload.Settings[t.opts.SettingsKey].(bool); ok && t.opts.SettingsKey != "" {
+		on = persisted
+	}
+
+	t.mu.Lock()
+	t.states[event.Context] = on
+	t.mu.Unlock()
+
+	if on != (event.Payload.State != toggleOff) {
+		t.s.SetState(event.Context, stateOf(on))
+	}
+}
+
+// toggle switches the logical state following a keyUp event.
+func (t *Toggle) toggle(ctx context.Context, event *ReceivedEvent) error {
+	t.mu.Lock()
+	previous, ok := t.states[event.Context]
+	t.mu.Unlock()
+
+	if !ok {
+		previous = event.Payload.State != toggleOff
+	}
+
+	target := !previous
+	if event.Payload.IsInMultiAction {
+		target = event.Payload.UserDesiredState != toggleOff
+	}
+
+	callback := t.opts.OnOff
+	if target {
+		callback = t.opts.OnOn
+	}
+
+	if callback != nil {
+		if err := callback(ctx, event); err != nil {
+			// The Stream Deck application may already display the target state
+			t.s.SetState(event.Context, stateOf(previous))
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	t.states[event.Context] = target
+	t.mu.Unlock()
+
+	t.s.SetState(event.Context, stateOf(target))
+	if t.opts.SettingsKey != "" {
+		t.s.MergeSettings(event.Context, map[string]interface{}{t.opts.SettingsKey: target})
+	}
+
+	return nil
+}
+
+// stateOf returns the action state displaying given logical state.
+func stateOf(on bool) uint8 {
+	if on {
+		return toggleOn
+	}
+
+	return toggleOff
+}
@@ -0,0 +1,158 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShutdown is returned when waiting for an event while the plugin shuts down.
+var ErrShutdown = errors.New("plugin is shutting down")
+
+// Predicate matches incoming events.
+type Predicate func(event *ReceivedEvent) bool
+
+// MatchEvent matches events with one of given names.
+func MatchEvent(names ...EventName) Predicate {
+	return func(event *ReceivedEvent) bool {
+		for _, name := range names {
+			if event.Event == name {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// MatchContext matches events of the action instance with given context.
+func MatchContext(context string) Predicate {
+	return func(event *ReceivedEvent) bool {
+		return event.Context == context
+	}
+}
+
+// MatchAll matches events matched by all given predicates.
+func MatchAll(predicates ...Predicate) Predicate {
+	return func(event *ReceivedEvent) bool {
+		for _, p := range predicates {
+			if !p(event) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// waiter receives dispatched events matching its predicate. deliver is called from the process loop, it must not block.
+type waiter struct {
+	predicate Predicate
+	deliver   func(event *ReceivedEvent)
+}
+
+// waiters feeds WaitFor and Subscribe from the process loop, so they never hold a dispatcher worker.
+// Like handlers, they do not see bus messages.
+type waiters struct {
+	mu    sync.RWMutex
+	items map[*waiter]struct{}
+}
+
+func newWaiters() *waiters {
+	return &waiters{items: make(map[*waiter]struct{})}
+}
+
+// add registers a waiter and returns a function unregistering it.
+func (w *waiters) add(predicate Predicate, deliver func(event *ReceivedEvent)) func() {
+	item := &waiter{predicate: predicate, deliver: deliver}
+
+	w.mu.Lock()
+	w.items[item] = struct{}{}
+	w.mu.Unlock()
+
+	return func() {
+		w.mu.Lock()
+		delete(w.items, item)
+		w.mu.Unlock()
+	}
+}
+
+// observe delivers given event to matching waiters.
+func (w *waiters) observe(event *ReceivedEvent) {
+	w.mu.RLock()
+	items := make([]*waiter, 0, len(w.items))
+	for item := range w.items {
+		items = append(items, item)
+	}
+	w.mu.RUnlock()
+
+	for _, item := range items {
+		if item.predicate(event) {
+			item.deliver(event)
+		}
+	}
+}
+
+// WaitFor blocks until an incoming event matches predicate and returns it.
+// It fails when ctx is done or the plugin shuts down.
+func (s *StreamDeck) WaitFor(ctx context.Context, predicate func(event *ReceivedEvent) bool) (*ReceivedEvent, error) {
+	ch := make(chan *ReceivedEvent, 1)
+	unregister := s.waiters.add(predicate, func(event *ReceivedEvent) {
+		select {
+		case ch <- event:
+		default: // already matched
+		}
+	})
+	defer unregister()
+
+	select {
+	case event := <-ch:
+		return event, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.done:
+		return nil, ErrShutdown
+	}
+}
+
+// Subscribe returns a channel receiving incoming events matching predicate, holding up to buffer events.
+// Matching events are dropped while the channel is full, so it must be read promptly.
+// The channel is closed when ctx is done or the plugin shuts down.
+func (s *StreamDeck) Subscribe(ctx context.Context, predicate func(event *ReceivedEvent) bool, buffer int) <-chan *ReceivedEvent {
+	ch := make(chan *ReceivedEvent, buffer)
+
+	var mu sync.Mutex
+	closed := false
+
+	unregister := s.waiters.add(predicate, func(event *ReceivedEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if closed {
+			return
+		}
+
+		select {
+		case ch <- event:
+		default:
+			s.Logf("[WARN] subscription is full, dropping event [%s] for action [%s]", event.Event, event.Action)
+		}
+	})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.done:
+		}
+
+		unregister()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		closed = true
+		close(ch)
+	}()
+
+	return ch
+}
@@ -0,0 +1,148 @@
+package sdk
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// zoneColors are used in turn to draw zones on the debug overlay.
+var zoneColors = []color.RGBA{
+	{R: 0xe6, G: 0x19, B: 0x4b, A: 0xff},
+	{R: 0x3c, G: 0xb4, B: 0x4b, A: 0xff},
+	{R: 0x43, G: 0x63, B: 0xd8, A: 0xff},
+	{R: 0xff, G: 0xe1, B: 0x19, A: 0xff},
+	{R: 0xf5, G: 0x82, B: 0x31, A: 0xff},
+	{R: 0x91, G: 0x1e, B: 0xb4, A: 0xff},
+}
+
+// ZoneHandlerFunc handles a tap in a zone of the touch display.
+type ZoneHandlerFunc func(zone string, event *ReceivedEvent) error
+
+// TapZone is a named area of the touch display of an encoder action.
+type TapZone struct {
+	// Name of the zone, given to its handlers.
+	Name string
+
+	// Rect is the area of the zone, in pixels within the LayoutWidth x LayoutHeight touch display.
+	Rect image.Rectangle
+
+	// OnTap handles short taps in the zone.
+	OnTap ZoneHandlerFunc
+
+	// OnLongTap handles long taps in the zone.
+	OnLongTap ZoneHandlerFunc
+}
+
+// ZoneMap dispatches touchTap events of an action instance to the zone which was tapped.
+type ZoneMap struct {
+	s          *StreamDeck
+	unregister func()
+	context    string
+
+	mu    sync.RWMutex
+	zones []TapZone
+}
+
+// NewZoneMap creates a ZoneMap of given zones for the action with given context and registers it as a handler.
+// When zones overlap, the first declared one wins.
+func (s *StreamDeck) NewZoneMap(context string, zones ...TapZone) *ZoneMap {
+	z := &ZoneMap{
+		s:       s,
+		context: context,
+		zones:   zones,
+	}
+
+	z.unregister = s.On(contextless(z.Handle))
+	return z
+}
+
+// SetZones replaces the zones of the map.
+func (z *ZoneMap) SetZones(zones ...TapZone) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	z.zones = zones
+}
+
+// Hit returns the zone containing given position.
+func (z *ZoneMap) Hit(x, y int) (TapZone, bool) {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+
+	p := image.Pt(x, y)
+	for _, zone := range z.zones {
+		if p.In(zone.Rect) {
+			return zone, true
+		}
+	}
+
+	return TapZone{}, false
+}
+
+// Close unregisters the ZoneMap handler, it stops handling events.
+func (z *ZoneMap) Close() {
+	z.unregister()
+}
+
+// Handle implements Handler.
+func (z *ZoneMap) Handle(event *ReceivedEvent) error {
+	if event.Context != z.context || event.Event != TouchTap || event.Payload == nil {
+		return nil
+	}
+
+	zone, ok := z.Hit(event.Payload.TapPos[0], event.Payload.TapPos[1])
+	if !ok {
+		return nil
+	}
+
+	h := zone.OnTap
+	if event.Payload.Hold {
+		h = zone.OnLongTap
+	}
+
+	if h == nil {
+		return nil
+	}
+
+	return h(zone.Name, event)
+}
+
+// DebugImage draws the zones on a transparent touch display sized image,
+// each zone filled with a translucent color and outlined with the same opaque color.
+func (z *ZoneMap) DebugImage() image.Image {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+
+	img := image.NewRGBA(image.Rect(0, 0, LayoutWidth, LayoutHeight))
+	for i := len(z.zones) - 1; i >= 0; i-- {
+		c := zoneColors[i%len(zoneColors)]
+		r := z.zones[i].Rect.Intersect(img.Bounds())
+		if r.Empty() {
+			continue
+		}
+
+		fill := color.NRGBA{R: c.R, G: c.G, B: c.B, A: 0x60}
+		draw.Draw(img, r, image.NewUniform(fill), image.Point{}, draw.Src)
+
+		border := image.NewUniform(c)
+		draw.Draw(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), border, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), border, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y), border, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y), border, image.Point{}, draw.Src)
+	}
+
+	return img
+}
+
+// ShowDebugOverlay displays DebugImage on the touch display, switching the action to the CanvasLayout.
+func (z *ZoneMap) ShowDebugOverlay() error {
+	overlay, err := EncodeImage(z.DebugImage())
+	if err != nil {
+		return err
+	}
+
+	z.s.SetFeedbackLayout(z.context, CanvasLayout)
+	return z.s.SendFeedback(z.context, &CanvasFeedback{FullCanvas: &PixmapItem{Value: overlay}})
+}